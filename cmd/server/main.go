@@ -1,26 +1,41 @@
 package main
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
 
+	"goKit/internal/interface/http/handler"
 	httpInterface "goKit/internal/interface/http/router"
+	"goKit/migrations"
 
 	"goKit/pkg/kit"
 	"goKit/pkg/kit/db"
+	"goKit/pkg/kit/db/migrate"
+	"goKit/pkg/kit/db/outbox"
+	"goKit/pkg/kit/lock"
+	"goKit/pkg/kit/log"
 	"goKit/pkg/kit/rpc"
 	"goKit/pkg/kit/web"
+	"goKit/pkg/kit/web/idempotency"
 )
 
 type AppConfig struct {
-	Web      web.Config `mapstructure:"web"`
-	RPC      rpc.Config `mapstructure:"rpc"`
-	Database db.Config  `mapstructure:"database"`
+	Web         web.Config           `mapstructure:"web"`
+	RPC         rpc.Config           `mapstructure:"rpc"`
+	Database    db.Config            `mapstructure:"database"`
+	Databases   map[string]db.Config `mapstructure:"databases"` // 具名数据源，通过 name:"<key>" 标签注入
+	Migrate     migrate.Config       `mapstructure:"migrate"`
+	Outbox      outbox.Config        `mapstructure:"outbox"`
+	Lock        lock.Config          `mapstructure:"lock"`
+	Idempotency idempotency.Config   `mapstructure:"idempotency"`
+	Log         log.Config           `mapstructure:"log"`
 }
 
 func LoadConfig() (*AppConfig, error) {
@@ -39,29 +54,82 @@ func LoadConfig() (*AppConfig, error) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 具名数据源需要在构建依赖图前确定，因此先加载配置
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	fx.New(
-		fx.Provide(func() *slog.Logger {
-			return slog.New(slog.NewJSONHandler(os.Stdout, nil))
-		}),
-		fx.Provide(LoadConfig),
+		// 自动迁移需要等待迁移锁 (migrate.lock_timeout)，放宽 fx 默认 15s 的启动超时
+		fx.StartTimeout(migrate.StartTimeout(cfg.Migrate, fx.DefaultTimeout)),
+		fx.Supply(cfg),
 		fx.Provide(
 			web.AsMiddlewares(func() fiber.Handler {
 				return cors.New() // 使用 fiber/middleware/cors
 			}),
+			web.AsMiddlewares(func() fiber.Handler {
+				// 每个请求开启写后读会话，配合 database.read_your_writes 使用
+				return func(c *fiber.Ctx) error {
+					c.SetUserContext(db.WithReadYourWrites(c.UserContext()))
+					return c.Next()
+				}
+			}),
+			web.AsMiddlewares(func() fiber.Handler {
+				// 绑定租户，配合 database.tenant 使用；生产环境应从认证信息 (如 JWT claims) 中获取，而非信任请求头。
+				// schema 模式只为 tenant.allow 中 (或 Resolve 校验通过) 的租户建立连接池
+				return func(c *fiber.Ctx) error {
+					if tenant := strings.Clone(c.Get("X-Tenant-ID")); tenant != "" {
+						ctx := db.WithTenant(c.UserContext(), tenant)
+						c.SetUserContext(log.WithFields(ctx, slog.String("tenant", tenant)))
+					}
+					return c.Next()
+				}
+			}),
 		),
+		fx.Provide(func(cfg *AppConfig) log.Config { return cfg.Log }),
 		fx.Provide(func(cfg *AppConfig) web.Config { return cfg.Web }),
 		fx.Provide(func(cfg *AppConfig) rpc.Config { return cfg.RPC }),
 		fx.Provide(func(cfg *AppConfig) db.Config { return cfg.Database }),
+		fx.Provide(func(cfg *AppConfig) migrate.Config { return cfg.Migrate }),
+
+		// 需在 kit.Module 之前，保证迁移先于服务启动 (migrate.auto 开启时生效)；迁移前会先完成数据库连通性检查
+		migrate.OnStart(migrations.FS),
 
 		kit.Module,
+		db.NamedClients(cfg.Databases),
+
+		// 分布式锁与选主，多实例部署时 Outbox Relay 只在 leader 上运行
+		fx.Provide(func(cfg *AppConfig) lock.Config { return cfg.Lock }),
+		lock.Module,
+
+		// POST 幂等：携带 Idempotency-Key 的重试回放首次响应，幂等键按租户隔离
+		fx.Provide(func(cfg *AppConfig) idempotency.Config { return cfg.Idempotency }),
+		fx.Supply(idempotency.ScopeFunc(func(c *fiber.Ctx) string { return c.Get("X-Tenant-ID") })),
+		idempotency.Module,
+
+		// 事务发件箱，接入消息系统后替换为自己的 Publisher
+		fx.Provide(func(cfg *AppConfig) outbox.Config { return cfg.Outbox }),
+		fx.Provide(outbox.NewLogPublisher),
+		outbox.Module,
 
 		// === 3. 统一路由管理器 ===
+		fx.Provide(handler.NewAdminHandler),
 		fx.Provide(httpInterface.NewRouter),
 
 		// === 4. 启动时执行路由注册 ===
-		fx.Invoke(func(app *fiber.App, router *httpInterface.Router) {
+		fx.Invoke(func(app *fiber.App, router *httpInterface.Router) error {
 			// 一键注册所有路由，Main 函数不再关心具体有哪些业务 Handler
-			router.Register(app)
+			return router.Register(app)
 		}),
 	).Run()
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"goKit/migrations"
+	"goKit/pkg/kit/db"
+	"goKit/pkg/kit/db/migrate"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up            执行全部未应用的迁移
+  down [n]      回滚最近 n 个迁移 (默认 1)
+  status        查看迁移状态
+  create <name> 生成新的 up/down 迁移文件`
+
+// runMigrate 处理 migrate 子命令，不启动 HTTP/gRPC 服务
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	cfg, err := LoadConfig()
+
+	// create 只生成文件，不依赖配置与数据库
+	if args[0] == "create" {
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		dir := migrate.DefaultConfig().Dir
+		if err == nil {
+			if cfg.Migrate.Dir != "" {
+				dir = cfg.Migrate.Dir
+			}
+			dir = migrate.DriverDir(dir, cfg.Database.Driver)
+		}
+		files, err := migrate.Create(dir, args[1])
+		for _, f := range files {
+			fmt.Println("created", f)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := db.NewClient(cfg.Database, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err = client.Connect(context.Background()); err != nil {
+		return err
+	}
+	// 建表、建索引等 DDL 可能远超 database.query_timeout，迁移不设语句超时
+	ctx := db.WithQueryTimeout(context.Background(), 0)
+	m := migrate.New(client, migrations.FS, cfg.Migrate)
+
+	switch args[0] {
+	case "up":
+		done, err := m.Up(ctx)
+		for _, mg := range done {
+			fmt.Printf("up   %d_%s\n", mg.Version, mg.Name)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps: %s", args[1])
+			}
+		}
+		done, err := m.Down(ctx, steps)
+		for _, mg := range done {
+			fmt.Printf("down %d_%s\n", mg.Version, mg.Name)
+		}
+		return err
+	case "status":
+		list, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range list {
+			appliedAt := "pending"
+			if st.Applied {
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", st.Version, st.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
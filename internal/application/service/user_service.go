@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"strconv"
 
 	"goKit/internal/application/dto"
 	"goKit/internal/domain/entity"
 	"goKit/internal/domain/repository"
 	"goKit/pkg/kit/db"
+	"goKit/pkg/kit/db/outbox"
 )
 
 type UserService struct {
@@ -31,8 +33,16 @@ func (s *UserService) CreateUser(ctx context.Context, req dto.CreateUserReq) (ui
 			return err
 		}
 		userID = user.ID
-		return nil
-	})
+
+		// 领域事件与用户数据在同一事务中写入 outbox，提交后由 Relay 投递
+		event, err := outbox.NewEvent("user.created", strconv.FormatUint(user.ID, 10), dto.UserResp{
+			ID: user.ID, Name: user.Name, Email: user.Email,
+		})
+		if err != nil {
+			return err
+		}
+		return outbox.Add(ctx, event)
+	}, db.WithRetry(db.DefaultRetryPolicy())) // 死锁/锁等待超时自动重试
 	return userID, err
 }
 
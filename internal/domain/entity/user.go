@@ -1,11 +1,16 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"goKit/pkg/kit/db"
+)
 
 type User struct {
-	ID        uint64    `gorm:"primaryKey;autoIncrement"`
-	Name      string    `gorm:"size:64;not null"`
-	Email     string    `gorm:"size:128;uniqueIndex"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID        uint64     `gorm:"primaryKey;autoIncrement"`
+	Name      string     `gorm:"size:64;not null"`
+	Email     string     `gorm:"size:128;uniqueIndex"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime"`
+	Version   db.Version `gorm:"not null;default:1"` // 乐观锁
 }
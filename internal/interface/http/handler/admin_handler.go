@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"time"
+
+	"goKit/internal/interface/http/response"
+	"goKit/pkg/kit/db"
+	"goKit/pkg/kit/log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminHandler 运维接口，只应暴露在内网
+type AdminHandler struct {
+	db *db.Client
+}
+
+func NewAdminHandler(client *db.Client) *AdminHandler {
+	return &AdminHandler{db: client}
+}
+
+// SlowPlans 最近的慢查询执行计划 (需开启 database.explain)
+func (h *AdminHandler) SlowPlans(c *fiber.Ctx) error {
+	return response.Success(c, h.db.SlowPlans())
+}
+
+// LogLevel 当前全局日志级别与按名称的覆盖
+func (h *AdminHandler) LogLevel(c *fiber.Ctx) error {
+	return response.Success(c, log.Levels())
+}
+
+// SetLogLevelReq 修改日志级别。Name 为空时修改全局级别；Name 非空且 Level 为空时删除该名称的覆盖
+type SetLogLevelReq struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+	TTL   string `json:"ttl"` // 如 "10m"，到期后自动恢复，为空表示不恢复
+}
+
+// SetLogLevel 运行期修改日志级别
+func (h *AdminHandler) SetLogLevel(c *fiber.Ctx) error {
+	var req SetLogLevelReq
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrBadRequest("JSON解析失败，请检查请求体格式")
+	}
+	if req.Name != "" && req.Level == "" {
+		log.ResetNamedLevel(req.Name)
+		return response.Success(c, log.Levels())
+	}
+	level, err := log.ParseLevel(req.Level)
+	if err != nil {
+		return response.ErrBadRequest("level 仅支持 debug / info / warn / error")
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+			return response.ErrBadRequest("ttl 格式错误，如 10m")
+		}
+	}
+	if req.Name != "" {
+		log.SetNamedLevel(req.Name, level, ttl)
+	} else {
+		log.SetLevel(level, ttl)
+	}
+	return response.Success(c, log.Levels())
+}
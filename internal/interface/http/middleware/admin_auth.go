@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"goKit/internal/interface/http/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminAuth 校验运维接口的 Bearer Token
+func AdminAuth(token string) fiber.Handler {
+	want := []byte(token)
+	return func(c *fiber.Ctx) error {
+		got, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), want) != 1 {
+			return response.ErrUnauthorized("")
+		}
+		return c.Next()
+	}
+}
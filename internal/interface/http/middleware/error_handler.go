@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"log/slog"
 
 	"goKit/internal/interface/http/response" // 引入响应包
+	"goKit/pkg/kit/db"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -15,6 +17,15 @@ func ErrorHandler(logger *slog.Logger) fiber.Handler {
 			return nil
 		}
 
+		// 乐观锁冲突统一映射为 409，即使已被包装成其他 AppError
+		if errors.Is(err, db.ErrStaleObject) {
+			err = response.ErrConflict("")
+		}
+		// 查询超时映射为 504，保留原始错误用于日志
+		if db.IsTimeout(err) {
+			err = response.ErrTimeout(err, "")
+		}
+
 		// 拦截自定义的 AppError
 		if appErr, ok := err.(*response.AppError); ok {
 			if appErr.HTTPCode >= 500 {
@@ -8,7 +8,9 @@ const (
 	CodeUnauthorized   = 40100
 	CodeForbidden      = 40300
 	CodeNotFound       = 40400
+	CodeConflict       = 40900
 	CodeInternalServer = 50000
+	CodeTimeout        = 50400
 )
 
 type AppError struct {
@@ -25,14 +27,39 @@ func (e *AppError) Error() string {
 	return e.Message
 }
 
+func (e *AppError) Unwrap() error {
+	return e.RawError
+}
+
 func ErrBadRequest(msg string) *AppError {
 	return &AppError{HTTPCode: 400, BusinessCode: CodeParamError, Message: msg}
 }
 
+func ErrUnauthorized(msg string) *AppError {
+	if msg == "" {
+		msg = "未授权"
+	}
+	return &AppError{HTTPCode: 401, BusinessCode: CodeUnauthorized, Message: msg}
+}
+
 func ErrNotFound(msg string) *AppError {
 	return &AppError{HTTPCode: 404, BusinessCode: CodeNotFound, Message: msg}
 }
 
+func ErrConflict(msg string) *AppError {
+	if msg == "" {
+		msg = "数据已被修改，请刷新后重试"
+	}
+	return &AppError{HTTPCode: 409, BusinessCode: CodeConflict, Message: msg}
+}
+
+func ErrTimeout(err error, msg string) *AppError {
+	if msg == "" {
+		msg = "请求处理超时，请稍后再试"
+	}
+	return &AppError{HTTPCode: 504, BusinessCode: CodeTimeout, Message: msg, RawError: err}
+}
+
 func ErrInternal(err error, msg string) *AppError {
 	if msg == "" {
 		msg = "服务器开小差了，请稍后再试"
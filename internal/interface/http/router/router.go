@@ -1,7 +1,10 @@
 package router
 
 import (
+	"errors"
+	"goKit/internal/interface/http/handler"
 	"goKit/internal/interface/http/middleware"
+	"goKit/pkg/kit/web"
 	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,6 +19,8 @@ type Router struct {
 type RouterIn struct {
 	fx.In
 	Logger *slog.Logger
+	Web    web.Config
+	Admin  *handler.AdminHandler
 }
 
 // NewRouter 通过 Fx 依赖注入所有的 Handler
@@ -26,9 +31,23 @@ func NewRouter(par RouterIn) *Router {
 }
 
 // Register 统一注册路由树
-func (r *Router) Register(app *fiber.App) {
+func (r *Router) Register(app *fiber.App) error {
 	// 全局 API 分组
 	v1 := app.Group("/api/v1")
 	v1.Use(middleware.ErrorHandler(r.params.Logger))
 
+	// 运维接口，web.admin.enabled 开启后注册，需携带 Bearer Token
+	cfg := r.params.Web.Admin
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Token == "" {
+		return errors.New("web.admin.token is required when web.admin.enabled is true")
+	}
+	admin := app.Group("/admin")
+	admin.Use(middleware.ErrorHandler(r.params.Logger), middleware.AdminAuth(cfg.Token))
+	admin.Get("/db/slow-plans", r.params.Admin.SlowPlans)
+	admin.Get("/log/level", r.params.Admin.LogLevel)
+	admin.Put("/log/level", r.params.Admin.SetLogLevel)
+	return nil
 }
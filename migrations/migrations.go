@@ -0,0 +1,8 @@
+// Package migrations 打包数据库迁移文件，按驱动分目录 (mysql、postgres、sqlite) 存放，
+// 通过 `go run ./cmd/server migrate create <name>` 在当前驱动的目录下生成新版本
+package migrations
+
+import "embed"
+
+//go:embed */*.sql
+var FS embed.FS
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `topic` VARCHAR(128) NOT NULL,
+  `key` VARCHAR(128) NOT NULL DEFAULT '',
+  `payload` LONGBLOB,
+  `attempts` BIGINT NOT NULL DEFAULT 0,
+  `next_attempt_at` DATETIME(3) NOT NULL,
+  `last_error` VARCHAR(512) DEFAULT NULL,
+  `delivered_at` DATETIME(3) DEFAULT NULL,
+  `dead_at` DATETIME(3) DEFAULT NULL,
+  `created_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_outbox_events_delivered_at` (`delivered_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1 @@
+DROP INDEX `idx_outbox_events_key_id` ON `outbox_events`;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+  id BIGSERIAL PRIMARY KEY,
+  topic VARCHAR(128) NOT NULL,
+  key VARCHAR(128) NOT NULL DEFAULT '',
+  payload BYTEA,
+  attempts BIGINT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL,
+  last_error VARCHAR(512) DEFAULT NULL,
+  delivered_at TIMESTAMPTZ DEFAULT NULL,
+  dead_at TIMESTAMPTZ DEFAULT NULL,
+  created_at TIMESTAMPTZ DEFAULT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_delivered_at ON outbox_events (delivered_at);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_outbox_events_key_id ON outbox_events (key, id);
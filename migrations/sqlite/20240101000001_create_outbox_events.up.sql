@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  topic TEXT NOT NULL,
+  key TEXT NOT NULL DEFAULT '',
+  payload BLOB,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at DATETIME NOT NULL,
+  last_error TEXT DEFAULT NULL,
+  delivered_at DATETIME DEFAULT NULL,
+  dead_at DATETIME DEFAULT NULL,
+  created_at DATETIME DEFAULT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_delivered_at ON outbox_events (delivered_at);
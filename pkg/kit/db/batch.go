@@ -0,0 +1,279 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const defaultBatchSize = 500
+
+// Stream 按主键升序流式读取满足条件的记录，每次从数据库读取 batchSize 行 (键集游标，不受偏移量影响)。
+// 迭代中途 break 会停止读取；出错时最后一次迭代返回 (nil, err)
+//
+//	for user, err := range db.Stream[entity.User](ctx, client, 1000, db.Eq("status", 1)) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Stream[T any](ctx context.Context, c *Client, batchSize int, specs ...Spec) iter.Seq2[*T, error] {
+	return NewRepository[T](c).Stream(ctx, batchSize, specs...)
+}
+
+// Process 见 Repository.Process
+func Process[T any](ctx context.Context, c *Client, opts BatchOptions, fn func(ctx context.Context, batch []*T) error, specs ...Spec) (Progress, error) {
+	return NewRepository[T](c).Process(ctx, opts, fn, specs...)
+}
+
+// Stream 按主键升序流式读取满足条件的记录
+func (r *Repository[T]) Stream(ctx context.Context, batchSize int, specs ...Spec) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		err := r.batches(ctx, nil, batchSize, specs, func(items []*T, _ any) bool {
+			for _, item := range items {
+				if !yield(item, nil) {
+					return false
+				}
+			}
+			return true
+		})
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// BatchOptions 批处理参数
+type BatchOptions struct {
+	Size       int            // 每批行数，默认 500
+	Workers    int            // 并发处理的批次数，默认 1
+	RateLimit  float64        // 每秒最多派发的批次数，<= 0 表示不限速
+	Checkpoint Checkpoint     // 断点存储，为空时每次从头开始
+	OnProgress func(Progress) // 每批完成后回调 (串行调用)
+}
+
+// Progress 批处理进度，Cursor 之前 (含) 的记录均已处理完成
+type Progress struct {
+	Batches int64         `json:"batches"`
+	Rows    int64         `json:"rows"`
+	Cursor  any           `json:"cursor"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Checkpoint 断点存储，cursor 为主键值的 JSON 编码
+type Checkpoint interface {
+	Load(ctx context.Context) ([]byte, error) // 不存在断点时返回 nil, nil
+	Save(ctx context.Context, cursor []byte) error
+}
+
+// FileCheckpoint 基于本地文件的断点存储，适合一次性的回填/导出任务
+type FileCheckpoint string
+
+func (f FileCheckpoint) Load(ctx context.Context) ([]byte, error) {
+	data, err := os.ReadFile(string(f))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (f FileCheckpoint) Save(ctx context.Context, cursor []byte) error {
+	tmp := string(f) + ".tmp"
+	if err := os.WriteFile(tmp, cursor, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, string(f))
+}
+
+// Process 按主键顺序分批读取记录并交给 fn 并发处理。
+// 读取是串行的 (键集游标)，处理由 Workers 个 goroutine 并行执行；断点只在其之前的批次全部完成后推进，
+// 因此中断后从断点恢复不会遗漏记录 (但可能重复处理部分批次，fn 需幂等)。
+// 任一批次失败或 ctx 取消时停止派发，等待进行中的批次结束后返回
+func (r *Repository[T]) Process(ctx context.Context, opts BatchOptions, fn func(ctx context.Context, batch []*T) error, specs ...Spec) (Progress, error) {
+	start := time.Now()
+	progress := Progress{}
+	if opts.Size <= 0 {
+		opts.Size = defaultBatchSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+
+	pk, err := r.primaryField()
+	if err != nil {
+		return progress, err
+	}
+	var cursor any
+	if opts.Checkpoint != nil {
+		raw, err := opts.Checkpoint.Load(ctx)
+		if err != nil {
+			return progress, fmt.Errorf("db: load checkpoint: %w", err)
+		}
+		if raw != nil {
+			v := reflect.New(pk.FieldType)
+			if err = json.Unmarshal(raw, v.Interface()); err != nil {
+				return progress, fmt.Errorf("db: decode checkpoint: %w", err)
+			}
+			cursor = v.Elem().Interface()
+		}
+	}
+	progress.Cursor = cursor
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		seq   int
+		items []*T
+		last  any
+	}
+	type result struct {
+		job
+		err error
+	}
+	jobs := make(chan job)
+	results := make(chan result)
+
+	// 读取：串行推进键集游标，按速率派发
+	var readErr error
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if opts.RateLimit > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.RateLimit))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		seq := 0
+		readErr = r.batches(runCtx, cursor, opts.Size, specs, func(items []*T, last any) bool {
+			if tick != nil && seq > 0 {
+				select {
+				case <-runCtx.Done():
+					return false
+				case <-tick:
+				}
+			}
+			select {
+			case jobs <- job{seq: seq, items: items, last: last}:
+				seq++
+				return true
+			case <-runCtx.Done():
+				return false
+			}
+		})
+	}()
+
+	// 处理
+	var wg sync.WaitGroup
+	for range opts.Workers {
+		wg.Go(func() {
+			for j := range jobs {
+				err := runCtx.Err()
+				if err == nil {
+					err = fn(runCtx, j.items)
+				}
+				results <- result{job: j, err: err}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// 汇总：按批次顺序推进断点
+	var firstErr error
+	pending := map[int]result{}
+	next := 0
+	for res := range results {
+		if firstErr != nil {
+			continue
+		}
+		if res.err != nil {
+			firstErr = fmt.Errorf("db: batch %d: %w", res.seq, res.err)
+			cancel()
+			continue
+		}
+		pending[res.seq] = res
+		for {
+			done, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			progress.Batches++
+			progress.Rows += int64(len(done.items))
+			progress.Cursor = done.last
+			progress.Elapsed = time.Since(start)
+			if opts.Checkpoint != nil {
+				if err := saveCheckpoint(ctx, opts.Checkpoint, done.last); err != nil {
+					firstErr = fmt.Errorf("db: save checkpoint: %w", err)
+					cancel()
+					break
+				}
+			}
+			if opts.OnProgress != nil {
+				opts.OnProgress(progress)
+			}
+		}
+	}
+	progress.Elapsed = time.Since(start)
+
+	if err := ctx.Err(); err != nil {
+		return progress, err
+	}
+	if firstErr != nil {
+		return progress, firstErr
+	}
+	return progress, readErr
+}
+
+func saveCheckpoint(ctx context.Context, cp Checkpoint, cursor any) error {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return cp.Save(ctx, raw)
+}
+
+// batches 以主键为键集游标，从 cursor 之后 (不含) 开始逐批读取，yield 返回 false 时停止
+func (r *Repository[T]) batches(ctx context.Context, cursor any, size int, specs []Spec, yield func(items []*T, last any) bool) error {
+	pk, err := r.primaryField()
+	if err != nil {
+		return err
+	}
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	col := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		tx := applySpecs(r.DB(ctx), specs)
+		if cursor != nil {
+			tx = tx.Where(clause.Gt{Column: col, Value: cursor})
+		}
+		var items []*T
+		if err := tx.Order(clause.OrderByColumn{Column: col}).Limit(size).Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		cursor, _ = pk.ValueOf(ctx, reflect.ValueOf(items[len(items)-1]))
+		if !yield(items, cursor) || len(items) < size {
+			return nil
+		}
+	}
+}
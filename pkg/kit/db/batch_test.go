@@ -0,0 +1,293 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type batchItem struct {
+	ID    int64 `gorm:"primaryKey"`
+	Group int
+}
+
+// newBatchClient 创建 SQLite Client，并写入 ID 为 1..n、Group 为 ID%2 的记录
+func newBatchClient(t *testing.T, n int) *Client {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Driver = "sqlite"
+	cfg.DSN = "file:" + filepath.Join(t.TempDir(), "batch.db")
+	cfg.Metrics = false
+	c, err := NewClient(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	db := c.GetDB(context.Background())
+	if err := db.AutoMigrate(&batchItem{}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= n; i++ {
+		if err := db.Create(&batchItem{ID: int64(i), Group: i % 2}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// countQueries 统计 Client 执行的查询语句数
+func countQueries(t *testing.T, c *Client) *int {
+	t.Helper()
+	var n int
+	if err := c.db.Callback().Query().After("gorm:query").Register("test:count", func(*gorm.DB) { n++ }); err != nil {
+		t.Fatal(err)
+	}
+	return &n
+}
+
+func ids(items []*batchItem) []int64 {
+	out := make([]int64, len(items))
+	for i, it := range items {
+		out[i] = it.ID
+	}
+	return out
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		specs       []Spec
+		stopAfter   int // 读取多少条后 break，0 表示读完
+		want        []int64
+		wantQueries int
+	}{
+		{"exact batches", 5, nil, 0, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 3},
+		{"short last batch", 3, nil, 0, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 4},
+		{"single batch", 100, nil, 0, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 1},
+		{"with spec", 2, []Spec{Eq("group", 0)}, 0, []int64{2, 4, 6, 8, 10}, 3},
+		{"no rows", 2, []Spec{Eq("group", 5)}, 0, nil, 1},
+		{"early break", 2, nil, 3, []int64{1, 2, 3}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newBatchClient(t, 10)
+			queries := countQueries(t, c)
+			var got []int64
+			for item, err := range Stream[batchItem](context.Background(), c, tt.size, tt.specs...) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, item.ID)
+				if len(got) == tt.stopAfter {
+					break
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("ids = %v, want %v", got, tt.want)
+			}
+			if *queries != tt.wantQueries {
+				t.Fatalf("queries = %d, want %d", *queries, tt.wantQueries)
+			}
+		})
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	c := newBatchClient(t, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []int64
+	var last error
+	for item, err := range Stream[batchItem](ctx, c, 2) {
+		if err != nil {
+			last = err
+			break
+		}
+		got = append(got, item.ID)
+		cancel()
+	}
+	if !slices.Equal(got, []int64{1, 2}) || !errors.Is(last, context.Canceled) {
+		t.Fatalf("ids = %v, err = %v; want [1 2] and context.Canceled", got, last)
+	}
+}
+
+// memCheckpoint 记录每次保存的断点
+type memCheckpoint struct {
+	mu    sync.Mutex
+	load  []byte
+	saves []string
+}
+
+func (m *memCheckpoint) Load(context.Context) ([]byte, error) {
+	return m.load, nil
+}
+
+func (m *memCheckpoint) Save(_ context.Context, cursor []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saves = append(m.saves, string(cursor))
+	return nil
+}
+
+var errBatch = errors.New("batch failed")
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    BatchOptions
+		load    string // 初始断点
+		cancel  int64  // 处理到包含该 ID 的批次时取消 ctx
+		fn      func(batch []*batchItem) error
+		wantErr error
+		// 断点按批次顺序推进，失败或取消后不再推进
+		wantSaves []string
+		wantRows  int64
+	}{
+		{
+			name:      "sequential",
+			opts:      BatchOptions{Size: 3},
+			wantSaves: []string{"3", "6", "9", "10"},
+			wantRows:  10,
+		},
+		{
+			name: "out of order completion",
+			opts: BatchOptions{Size: 3, Workers: 4},
+			// 越早的批次完成得越晚
+			fn: func(batch []*batchItem) error {
+				time.Sleep(time.Duration(10-batch[0].ID) * 5 * time.Millisecond)
+				return nil
+			},
+			wantSaves: []string{"3", "6", "9", "10"},
+			wantRows:  10,
+		},
+		{
+			name:      "resume from checkpoint",
+			opts:      BatchOptions{Size: 3},
+			load:      "6",
+			wantSaves: []string{"9", "10"},
+			wantRows:  4,
+		},
+		{
+			name: "first error stops",
+			opts: BatchOptions{Size: 3},
+			fn: func(batch []*batchItem) error {
+				if batch[0].ID == 4 {
+					return errBatch
+				}
+				return nil
+			},
+			wantErr:   errBatch,
+			wantSaves: []string{"3"},
+			wantRows:  3,
+		},
+		{
+			name: "error before slower earlier batch",
+			opts: BatchOptions{Size: 3, Workers: 2},
+			fn: func(batch []*batchItem) error {
+				if batch[0].ID == 4 {
+					return errBatch
+				}
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			},
+			wantErr:  errBatch,
+			wantRows: 0,
+		},
+		{
+			name:      "cancel",
+			opts:      BatchOptions{Size: 3},
+			cancel:    4,
+			wantErr:   context.Canceled,
+			wantSaves: []string{"3", "6"},
+			wantRows:  6,
+		},
+		{
+			name:      "rate limit",
+			opts:      BatchOptions{Size: 5, RateLimit: 100},
+			wantSaves: []string{"5", "10"},
+			wantRows:  10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newBatchClient(t, 10)
+			cp := &memCheckpoint{}
+			if tt.load != "" {
+				cp.load = []byte(tt.load)
+			}
+			tt.opts.Checkpoint = cp
+			var progress []Progress
+			tt.opts.OnProgress = func(p Progress) { progress = append(progress, p) }
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var mu sync.Mutex
+			var seen []int64
+			p, err := Process(ctx, c, tt.opts, func(ctx context.Context, batch []*batchItem) error {
+				mu.Lock()
+				seen = append(seen, ids(batch)...)
+				mu.Unlock()
+				if tt.cancel != 0 && slices.Contains(ids(batch), tt.cancel) {
+					cancel()
+				}
+				if tt.fn != nil {
+					return tt.fn(batch)
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(cp.saves, tt.wantSaves) {
+				t.Fatalf("checkpoints = %v, want %v", cp.saves, tt.wantSaves)
+			}
+			if p.Rows != tt.wantRows || len(progress) != len(tt.wantSaves) {
+				t.Fatalf("progress = %+v (%d callbacks), want %d rows", p, len(progress), tt.wantRows)
+			}
+			if tt.load != "" && slices.ContainsFunc(seen, func(id int64) bool { return id <= 6 }) {
+				t.Fatalf("processed %v before checkpoint %s", seen, tt.load)
+			}
+		})
+	}
+}
+
+func TestFileCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	cp := FileCheckpoint(filepath.Join(t.TempDir(), "cursor"))
+	if raw, err := cp.Load(ctx); raw != nil || err != nil {
+		t.Fatalf("Load = %q, %v; want nil, nil", raw, err)
+	}
+
+	c := newBatchClient(t, 10)
+	fail := true
+	fn := func(_ context.Context, batch []*batchItem) error {
+		if fail && batch[0].ID == 7 {
+			return errBatch
+		}
+		return nil
+	}
+	if _, err := Process(ctx, c, BatchOptions{Size: 3, Checkpoint: cp}, fn); !errors.Is(err, errBatch) {
+		t.Fatalf("err = %v, want %v", err, errBatch)
+	}
+	if raw, _ := cp.Load(ctx); string(raw) != "6" {
+		t.Fatalf("checkpoint = %q, want 6", raw)
+	}
+
+	fail = false
+	p, err := Process(ctx, c, BatchOptions{Size: 3, Checkpoint: cp}, fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Rows != 4 || p.Cursor != int64(10) {
+		t.Fatalf("progress = %+v, want 4 rows up to 10", p)
+	}
+}
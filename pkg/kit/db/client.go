@@ -2,35 +2,66 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"goKit/pkg/kit/log"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/plugin/dbresolver"
 )
 
+// DefaultName 默认 Client 名称，用于指标标签
+const DefaultName = "default"
+
 type Client struct {
-	db *gorm.DB
-}
+	name    string
+	db      *gorm.DB
+	logger  *SlogAdapter
+	metrics bool
+	startup StartupConfig
+	ready   atomic.Bool // 启动 Ping 成功后置为 true，停止时置为 false
 
-type txKey struct{}
+	replicas     *replicaSet  // 未配置从库时为 nil
+	prober       *prober      // 未开启从库健康探测时为 nil
+	explainer    *explainer   // 未开启慢查询 EXPLAIN 时为 nil
+	tenants      *tenantPools // 仅 schema 多租户模式
+	stickyWindow time.Duration
+}
 
+// NewClient 创建默认 Client (对应 database 配置)
 func NewClient(cfg Config, l *slog.Logger) (*Client, error) {
+	return NewNamedClient(DefaultName, cfg, l)
+}
+
+// NewNamedClient 创建具名 Client，日志附带 component=db 与 db=name 字段，指标以 name 作为 db 标签
+func NewNamedClient(name string, cfg Config, l *slog.Logger) (_ *Client, err error) {
+	base := l
+	l = log.NamedFrom(l, "db")
+	if name != DefaultName {
+		l = l.With(slog.String("db", name))
+	}
 	gormLogger := NewSlogAdapter(l, parseLogLevel(cfg.LogMode), cfg.SlowThreshold)
+	gormLogger.Redact, gormLogger.SampleRate = cfg.Redact, cfg.LogSampleRate
+	gormLogger.dqString = cfg.Driver == "mysql" || cfg.Driver == "sqlite"
 
 	gormConfig := &gorm.Config{
 		Logger:                 gormLogger,
 		SkipDefaultTransaction: true,
 		PrepareStmt:            true,
+		// 连通性检查由 StartLifecycle 按 Startup 配置 (超时 + 重试) 执行
+		DisableAutomaticPing: true,
 	}
 
-	var dialector gorm.Dialector
-	if cfg.Driver == "mysql" {
-		dialector = mysql.Open(cfg.DSN)
-	} else {
-		return nil, fmt.Errorf("unsupported driver: %s", cfg.Driver)
+	dialector, err := openDialector(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, err
 	}
 
 	db, err := gorm.Open(dialector, gormConfig)
@@ -38,46 +69,223 @@ func NewClient(cfg Config, l *slog.Logger) (*Client, error) {
 		return nil, err
 	}
 
+	client := &Client{name: name, db: db, logger: gormLogger, metrics: cfg.Metrics, startup: cfg.Startup, stickyWindow: cfg.ReadYourWrites}
+	var resolver *dbresolver.DBResolver
+	// 构建失败时释放已建立的连接池与 EXPLAIN worker，schema 租户模式下会按需反复构建
+	defer func() {
+		if err == nil {
+			return
+		}
+		if client.explainer != nil {
+			_ = client.explainer.close()
+		}
+		closePools(db, resolver)
+	}()
+
+	if err = registerVersionCallbacks(db); err != nil {
+		return nil, err
+	}
+	if err = registerTimeoutCallbacks(db, cfg.QueryTimeout); err != nil {
+		return nil, err
+	}
+	switch cfg.Tenant.Mode {
+	case "":
+	case TenantRow:
+		if err = registerTenantCallbacks(db, cfg.Tenant); err != nil {
+			return nil, err
+		}
+	case TenantSchema:
+		if client.tenants, err = newTenantPools(name, cfg, base); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("db: unknown tenant mode %q", cfg.Tenant.Mode)
+	}
+	if cfg.Metrics {
+		if err = db.Use(newMetricsPlugin(client.name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Explain.Enabled && cfg.SlowThreshold > 0 {
+		if client.explainer, err = newExplainer(name, cfg, db.Dialector.Name(), gormLogger); err != nil {
+			return nil, err
+		}
+		if err = client.explainer.register(db); err != nil {
+			return nil, err
+		}
+	}
+
 	if len(cfg.Replicas) > 0 {
 		var replicas []gorm.Dialector
 		for _, dsn := range cfg.Replicas {
-			replicas = append(replicas, mysql.Open(dsn))
+			replica, err := openDialector(cfg.Driver, dsn)
+			if err != nil {
+				return nil, err
+			}
+			replicas = append(replicas, replica)
 		}
-		err = db.Use(dbresolver.Register(dbresolver.Config{
-			Sources:  []gorm.Dialector{dialector},
-			Replicas: replicas,
-			Policy:   dbresolver.RandomPolicy{},
-		}))
+		set, err := newReplicaSet(cfg)
 		if err != nil {
 			return nil, err
 		}
+		policy, err := set.policy(cfg.ReplicaPolicy)
+		if err != nil {
+			return nil, err
+		}
+		// Sources 留空时 dbresolver 复用 db 自身的连接池作为主库，避免重复建连
+		resolver = dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   policy,
+		})
+		if err = db.Use(resolver); err != nil {
+			return nil, err
+		}
+		if err = set.bind(resolver); err != nil {
+			return nil, err
+		}
+		client.replicas = set
+		if err = client.registerRoutingCallbacks(db); err != nil {
+			return nil, err
+		}
+		if cfg.HealthCheck.Interval > 0 {
+			client.prober = newProber(cfg.HealthCheck, cfg.Driver, set, gormLogger)
+		}
 	}
 
-	sqlDB, err := db.DB()
+	pools, err := client.sqlPools()
 	if err != nil {
 		return nil, err
 	}
-	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	// 连接池参数同时作用于主库与各从库
+	for _, p := range pools {
+		p.db.SetMaxIdleConns(cfg.MaxIdleConns)
+		p.db.SetMaxOpenConns(cfg.MaxOpenConns)
+		p.db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.Metrics {
+		registerPoolMetrics(name, pools)
+	}
 
-	return &Client{db: db}, nil
+	return client, nil
 }
 
-func (c *Client) GetDB(ctx context.Context) *gorm.DB {
-	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
-		return tx
+// Name 返回 Client 名称，默认 Client 为 DefaultName
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Ping 检查主库与各从库的连通性，任一失败即返回错误
+func (c *Client) Ping(ctx context.Context) error {
+	pools, err := c.sqlPools()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, p := range pools {
+		if err := p.db.PingContext(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats 返回各连接池的统计信息，key 为 primary 或 replica-<下标>
+func (c *Client) Stats() map[string]sql.DBStats {
+	pools, err := c.sqlPools()
+	if err != nil {
+		return nil
+	}
+	stats := make(map[string]sql.DBStats, len(pools))
+	for _, p := range pools {
+		stats[p.name] = p.db.Stats()
 	}
-	return c.db.WithContext(ctx)
+	return stats
 }
 
-func (c *Client) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
-	return c.GetDB(ctx).Transaction(func(tx *gorm.DB) error {
-		txCtx := context.WithValue(ctx, txKey{}, tx)
-		return fn(txCtx)
+// Ready 启动检查已通过且尚未关闭
+func (c *Client) Ready() bool {
+	return c.ready.Load()
+}
+
+// Close 关闭主库、从库与租户连接池
+func (c *Client) Close() error {
+	c.ready.Store(false)
+	if c.metrics {
+		unregisterPoolMetrics(c.name)
+	}
+	pools, err := c.sqlPools()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, p := range pools {
+		errs = append(errs, p.db.Close())
+	}
+	if c.explainer != nil {
+		errs = append(errs, c.explainer.close())
+	}
+	if c.tenants != nil {
+		errs = append(errs, c.tenants.close())
+	}
+	return errors.Join(errs...)
+}
+
+// closePools 关闭主库与 resolver 已建立的从库连接池，用于构建失败时的清理
+func closePools(db *gorm.DB, resolver *dbresolver.DBResolver) {
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+	if resolver == nil {
+		return
+	}
+	_ = resolver.Call(func(pool gorm.ConnPool) error {
+		if sqlDB, ok := sqlDBOf(pool); ok {
+			_ = sqlDB.Close()
+		}
+		return nil
 	})
 }
 
+// sqlPools 返回主库与各从库的底层连接池，pool 为 primary 或 replica-<下标>
+func (c *Client) sqlPools() ([]sqlPool, error) {
+	primary, err := c.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	pools := []sqlPool{{name: "primary", db: primary}}
+	if c.replicas != nil {
+		for _, r := range c.replicas.replicas {
+			if sqlDB, ok := sqlDBOf(r.pool); ok {
+				pools = append(pools, sqlPool{name: "replica-" + strconv.Itoa(r.index), db: sqlDB})
+			}
+		}
+	}
+	return pools, nil
+}
+
+func (c *Client) GetDB(ctx context.Context) *gorm.DB {
+	if s, ok := scopeFrom(ctx); ok {
+		return s.db
+	}
+	if c.tenants != nil {
+		root, err := c.root(ctx)
+		if err != nil {
+			db := c.db.WithContext(ctx)
+			_ = db.AddError(err)
+			return db
+		}
+		if root != c {
+			return root.GetDB(ctx)
+		}
+	}
+	db := c.db.WithContext(ctx)
+	if c.replicas != nil && c.usePrimary(ctx) {
+		db = db.Clauses(dbresolver.Write)
+	}
+	return db
+}
+
 func parseLogLevel(lvl string) logger.LogLevel {
 	switch lvl {
 	case "silent":
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClientCleanupOnError(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+	}{
+		{"bad replica policy", func(cfg *Config) { cfg.ReplicaPolicy = "nope" }},
+		{"bad replica weights", func(cfg *Config) { cfg.ReplicaWeights = []int{1, 2, 3} }},
+		{"negative replica weight", func(cfg *Config) {
+			cfg.ReplicaPolicy, cfg.ReplicaWeights = "weighted", []int{-1}
+		}},
+		{"bad tenant mode", func(cfg *Config) { cfg.Tenant.Mode = "nope" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := DefaultConfig()
+			cfg.Driver = "sqlite"
+			cfg.DSN = "file:" + filepath.Join(dir, "primary.db")
+			cfg.Replicas = []string{"file:" + filepath.Join(dir, "replica.db")}
+			cfg.Metrics = false
+			cfg.SlowThreshold = time.Second
+			cfg.Explain.Enabled = true
+			tt.modify(&cfg)
+			l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			before := goroutines()
+			for range 10 {
+				if _, err := NewNamedClient("leak", cfg, l); err == nil {
+					t.Fatal("want error")
+				}
+			}
+			// 每个 *sql.DB 与 EXPLAIN worker 各持有一个协程，关闭后退出
+			var after int
+			for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+				if after = goroutines(); after < before+5 || time.Now().After(deadline) {
+					break
+				}
+			}
+			if after >= before+5 {
+				t.Fatalf("goroutines %d -> %d, client resources leaked", before, after)
+			}
+		})
+	}
+}
+
+// goroutines 统计当前协程数，不含 gorm 预编译语句缓存的清理协程 (gorm 不提供关闭方式)
+func goroutines() int {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	n := 0
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if !strings.Contains(g, "gorm.io/gorm/internal/lru") {
+			n++
+		}
+	}
+	return n
+}
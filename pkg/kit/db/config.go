@@ -3,23 +3,72 @@ package db
 import "time"
 
 type Config struct {
-	Driver          string        `mapstructure:"driver"`
-	DSN             string        `mapstructure:"dsn"`
-	Replicas        []string      `mapstructure:"replicas"`
-	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
-	MaxOpenConns    int           `mapstructure:"max_open_conns"`
-	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
-	LogMode         string        `mapstructure:"log_mode"`
-	SlowThreshold   time.Duration `mapstructure:"slow_threshold"`
+	Driver          string            `mapstructure:"driver"`
+	DSN             string            `mapstructure:"dsn"`
+	Replicas        []string          `mapstructure:"replicas"`
+	ReplicaPolicy   string            `mapstructure:"replica_policy"`   // random, round_robin, weighted, least_latency
+	ReplicaWeights  []int             `mapstructure:"replica_weights"`  // weighted 策略下与 Replicas 一一对应
+	ReadYourWrites  time.Duration     `mapstructure:"read_your_writes"` // 写后读主库的时间窗口，0 表示关闭
+	HealthCheck     HealthCheckConfig `mapstructure:"health_check"`
+	Startup         StartupConfig     `mapstructure:"startup"`
+	MaxIdleConns    int               `mapstructure:"max_idle_conns"`
+	MaxOpenConns    int               `mapstructure:"max_open_conns"`
+	ConnMaxLifetime time.Duration     `mapstructure:"conn_max_lifetime"`
+	QueryTimeout    time.Duration     `mapstructure:"query_timeout"` // ctx 没有截止时间时单条语句的超时，0 表示不限制
+	LogMode         string            `mapstructure:"log_mode"`
+	SlowThreshold   time.Duration     `mapstructure:"slow_threshold"`
+	Explain         ExplainConfig     `mapstructure:"explain"`         // 慢查询自动 EXPLAIN
+	Redact          RedactConfig      `mapstructure:"redact"`          // SQL 日志参数脱敏
+	LogSampleRate   float64           `mapstructure:"log_sample_rate"` // Info 级别 sql_exec 采样率，0 表示全量
+	Metrics         bool              `mapstructure:"metrics"`         // 导出 Prometheus 查询与连接池指标
+	Tenant          TenantConfig      `mapstructure:"tenant"`          // 多租户隔离
+}
+
+// HealthCheckConfig 从库健康探测配置
+type HealthCheckConfig struct {
+	Interval      time.Duration `mapstructure:"interval"`       // 探测间隔，0 表示关闭
+	Timeout       time.Duration `mapstructure:"timeout"`        // 单次探测超时，默认等于 Interval
+	MaxLag        time.Duration `mapstructure:"max_lag"`        // 复制延迟上限，0 表示不检查
+	FailThreshold int           `mapstructure:"fail_threshold"` // 连续失败多少次后摘除，默认 1
+}
+
+// StartupConfig 启动时的连通性检查，主库与从库均 Ping 通后才算启动成功
+type StartupConfig struct {
+	PingTimeout   time.Duration `mapstructure:"ping_timeout"`   // 单次 Ping 超时，默认 5s
+	PingRetries   int           `mapstructure:"ping_retries"`   // 失败后的重试次数，默认 0
+	RetryInterval time.Duration `mapstructure:"retry_interval"` // 重试间隔，默认 1s
 }
 
 func DefaultConfig() Config {
 	return Config{
 		Driver:          "mysql",
+		ReplicaPolicy:   PolicyRandom,
 		MaxIdleConns:    10,
 		MaxOpenConns:    100,
 		ConnMaxLifetime: time.Hour,
+		QueryTimeout:    10 * time.Second,
 		LogMode:         "error",
 		SlowThreshold:   200 * time.Millisecond,
+		Explain: ExplainConfig{
+			Interval: time.Second,
+			Timeout:  time.Second,
+			Buffer:   100,
+		},
+		Redact: RedactConfig{
+			Enabled: true,
+			Deny:    []string{"*password*", "*token*", "*secret*", "email", "phone", "mobile", "id_card"},
+		},
+		Metrics: true,
+		HealthCheck: HealthCheckConfig{
+			Interval:      5 * time.Second,
+			Timeout:       time.Second,
+			MaxLag:        10 * time.Second,
+			FailThreshold: 3,
+		},
+		Startup: StartupConfig{
+			PingTimeout:   5 * time.Second,
+			PingRetries:   3,
+			RetryInterval: time.Second,
+		},
 	}
 }
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+)
+
+// DialectorFactory 根据 DSN 构造 gorm.Dialector
+type DialectorFactory func(dsn string) gorm.Dialector
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]DialectorFactory{
+		"mysql":     mysql.Open,
+		"postgres":  postgres.Open,
+		"sqlite":    sqlite.Open, // 纯 Go 实现，无需 CGO，适合本地开发与单元测试
+		"sqlserver": sqlserver.Open,
+	}
+)
+
+// RegisterDriver 注册 (或覆盖) 一个驱动，需在 NewClient 之前调用
+func RegisterDriver(name string, factory DialectorFactory) {
+	if factory == nil {
+		panic("db: RegisterDriver factory is nil")
+	}
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[name] = factory
+}
+
+// openDialector 按驱动名称查找工厂并构造 Dialector
+func openDialector(driver, dsn string) (gorm.Dialector, error) {
+	driversMu.RLock()
+	factory, ok := drivers[driver]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported driver: %s", driver)
+	}
+	return factory(dsn), nil
+}
@@ -0,0 +1,398 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const explainStartKey = "kit:explain_start"
+
+// ExplainConfig 慢查询自动 EXPLAIN，仅对超过 SlowThreshold 的 SELECT 生效
+type ExplainConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"` // 两次 EXPLAIN 的最小间隔，默认 1s
+	Timeout  time.Duration `mapstructure:"timeout"`  // 单次 EXPLAIN 超时，默认 1s
+	Buffer   int           `mapstructure:"buffer"`   // 保留最近多少条慢查询计划，默认 100
+}
+
+// PlanSummary 执行计划摘要，EXPLAIN 完成后记录在 sql_slow_plan 日志上
+type PlanSummary struct {
+	FullScans    []string `json:"full_scans,omitempty"` // 发生全表扫描的表
+	Keys         []string `json:"keys,omitempty"`       // 使用到的索引
+	RowsExamined int64    `json:"rows_examined"`        // 优化器估算的扫描行数
+}
+
+func (p *PlanSummary) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.Int64("rows_examined", p.RowsExamined)}
+	if len(p.FullScans) > 0 {
+		attrs = append(attrs, slog.Any("full_scans", p.FullScans))
+	}
+	if len(p.Keys) > 0 {
+		attrs = append(attrs, slog.Any("keys", p.Keys))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// SlowPlan 慢查询及其执行计划。SQL 为参数化语句，不含参数值
+type SlowPlan struct {
+	Time     time.Time       `json:"time"`
+	DB       string          `json:"db"`
+	SQL      string          `json:"sql"`
+	Digest   string          `json:"digest"`
+	Duration time.Duration   `json:"duration"`
+	Summary  *PlanSummary    `json:"summary"`
+	Plan     json.RawMessage `json:"plan"`
+}
+
+// explainQueueSize 待执行 EXPLAIN 的队列长度，队列满时直接丢弃
+const explainQueueSize = 16
+
+// explainJob 一条待 EXPLAIN 的慢查询
+type explainJob struct {
+	ctx     context.Context
+	query   string
+	vars    []any
+	elapsed time.Duration
+}
+
+// explainer 在独立连接上由后台 worker 对慢 SELECT 执行 EXPLAIN，并保留最近的结果
+type explainer struct {
+	name     string
+	dialect  string
+	db       *sql.DB // 独立连接池 (最多 1 个连接)，不占用业务连接
+	cfg      ExplainConfig
+	slow     time.Duration
+	dqString bool
+	logger   *SlogAdapter
+
+	last atomic.Int64 // 上次执行 EXPLAIN 的时间 (ns)
+	jobs chan explainJob
+	quit chan struct{}
+	done chan struct{}
+
+	mu   sync.Mutex
+	ring []SlowPlan
+	next int
+	full bool
+}
+
+func newExplainer(name string, cfg Config, dialect string, l *SlogAdapter) (*explainer, error) {
+	switch dialect {
+	case "mysql", "postgres", "sqlite":
+	default:
+		return nil, fmt.Errorf("db: explain does not support %s", dialect)
+	}
+	dialector, err := openDialector(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	g, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard, DisableAutomaticPing: true})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := g.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	ec := cfg.Explain
+	if ec.Interval <= 0 {
+		ec.Interval = time.Second
+	}
+	if ec.Timeout <= 0 {
+		ec.Timeout = time.Second
+	}
+	if ec.Buffer <= 0 {
+		ec.Buffer = 100
+	}
+	e := &explainer{
+		name:     name,
+		dialect:  dialect,
+		db:       sqlDB,
+		cfg:      ec,
+		slow:     cfg.SlowThreshold,
+		dqString: l.dqString,
+		logger:   l,
+		ring:     make([]SlowPlan, ec.Buffer),
+		jobs:     make(chan explainJob, explainQueueSize),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+func (e *explainer) register(db *gorm.DB) error {
+	start := func(tx *gorm.DB) {
+		tx.InstanceSet(explainStartKey, time.Now())
+	}
+	end := func(tx *gorm.DB) {
+		begin, ok := tx.InstanceGet(explainStartKey)
+		if !ok || tx.Error != nil || tx.DryRun {
+			return
+		}
+		elapsed := time.Since(begin.(time.Time))
+		query := tx.Statement.SQL.String()
+		if elapsed <= e.slow || !isSelect(query) || !e.allow() {
+			return
+		}
+		// 不阻塞业务请求：交给后台 worker，队列满时丢弃本次
+		select {
+		case e.jobs <- explainJob{
+			ctx:     context.WithoutCancel(tx.Statement.Context),
+			query:   query,
+			vars:    slices.Clone(tx.Statement.Vars),
+			elapsed: elapsed,
+		}:
+		default:
+		}
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Query().Before("gorm:query").Register("kit:explain_start", start),
+		cb.Query().After("gorm:query").Register("kit:explain_end", end),
+		cb.Row().Before("gorm:row").Register("kit:explain_start", start),
+		cb.Row().After("gorm:row").Register("kit:explain_end", end),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run 串行执行队列中的 EXPLAIN，直到 close
+func (e *explainer) run() {
+	defer close(e.done)
+	for {
+		select {
+		case <-e.quit:
+			return
+		case job := <-e.jobs:
+			e.handle(job)
+		}
+	}
+}
+
+func (e *explainer) handle(job explainJob) {
+	summary, plan, err := e.explain(job.ctx, job.query, job.vars)
+	if err != nil {
+		e.logger.explainFailed(job.ctx, err)
+		return
+	}
+	p := SlowPlan{
+		Time:     time.Now(),
+		DB:       e.name,
+		SQL:      job.query,
+		Digest:   digest(job.query, e.dqString),
+		Duration: job.elapsed,
+		Summary:  summary,
+		Plan:     plan,
+	}
+	e.record(p)
+	e.logger.slowPlan(job.ctx, &p)
+}
+
+// close 等待进行中的 EXPLAIN 结束并关闭独立连接，队列中剩余的任务被丢弃
+func (e *explainer) close() error {
+	close(e.quit)
+	<-e.done
+	return e.db.Close()
+}
+
+// allow 限流：Interval 内最多执行一次 EXPLAIN
+func (e *explainer) allow() bool {
+	now := time.Now().UnixNano()
+	last := e.last.Load()
+	if now-last < int64(e.cfg.Interval) {
+		return false
+	}
+	return e.last.CompareAndSwap(last, now)
+}
+
+func isSelect(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	return len(query) >= 6 && strings.EqualFold(query[:6], "SELECT")
+}
+
+// explain 执行 EXPLAIN 并提取摘要，ctx 已脱离业务请求的取消
+func (e *explainer) explain(ctx context.Context, query string, vars []any) (*PlanSummary, json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+
+	switch e.dialect {
+	case "mysql":
+		var raw string
+		if err := e.db.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+query, vars...).Scan(&raw); err != nil {
+			return nil, nil, err
+		}
+		return summarizeMySQL([]byte(raw))
+	case "postgres":
+		var raw string
+		if err := e.db.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+query, vars...).Scan(&raw); err != nil {
+			return nil, nil, err
+		}
+		return summarizePostgres([]byte(raw))
+	default:
+		return e.explainSQLite(ctx, query, vars)
+	}
+}
+
+// summarizeMySQL 遍历 FORMAT=JSON 输出中的所有 table 节点
+func summarizeMySQL(raw []byte) (*PlanSummary, json.RawMessage, error) {
+	var plan any
+	if err := json.Unmarshal(raw, &plan); err != nil {
+		return nil, nil, err
+	}
+	s := &PlanSummary{}
+	var walk func(v any)
+	walk = func(v any) {
+		switch node := v.(type) {
+		case map[string]any:
+			if t, ok := node["table"].(map[string]any); ok {
+				name, _ := t["table_name"].(string)
+				if t["access_type"] == "ALL" {
+					s.FullScans = append(s.FullScans, name)
+				}
+				if key, ok := t["key"].(string); ok {
+					s.Keys = append(s.Keys, name+"."+key)
+				}
+				if rows, ok := t["rows_examined_per_scan"].(float64); ok {
+					s.RowsExamined += int64(rows)
+				}
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		case []any:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(plan)
+	return s, raw, nil
+}
+
+// summarizePostgres 遍历 FORMAT JSON 输出的计划树，扫描行数取叶子节点估算行数之和
+func summarizePostgres(raw []byte) (*PlanSummary, json.RawMessage, error) {
+	var plans []struct {
+		Plan map[string]any `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &plans); err != nil {
+		return nil, nil, err
+	}
+	s := &PlanSummary{}
+	var walk func(node map[string]any)
+	walk = func(node map[string]any) {
+		relation, _ := node["Relation Name"].(string)
+		if node["Node Type"] == "Seq Scan" {
+			s.FullScans = append(s.FullScans, relation)
+		}
+		if index, ok := node["Index Name"].(string); ok {
+			s.Keys = append(s.Keys, relation+"."+index)
+		}
+		children, _ := node["Plans"].([]any)
+		if len(children) == 0 {
+			if rows, ok := node["Plan Rows"].(float64); ok {
+				s.RowsExamined += int64(rows)
+			}
+		}
+		for _, child := range children {
+			if m, ok := child.(map[string]any); ok {
+				walk(m)
+			}
+		}
+	}
+	for _, p := range plans {
+		walk(p.Plan)
+	}
+	return s, raw, nil
+}
+
+// explainSQLite 基于 EXPLAIN QUERY PLAN 的 detail 列，SQLite 不提供行数估算
+func (e *explainer) explainSQLite(ctx context.Context, query string, vars []any) (*PlanSummary, json.RawMessage, error) {
+	rows, err := e.db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, vars...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	s := &PlanSummary{}
+	var details []string
+	for rows.Next() {
+		var (
+			id, parent, notUsed int
+			detail              string
+		)
+		if err = rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return nil, nil, err
+		}
+		details = append(details, detail)
+		fields := strings.Fields(detail)
+		if len(fields) < 2 {
+			continue
+		}
+		table := fields[1]
+		if table == "TABLE" && len(fields) > 2 {
+			table = fields[2]
+		}
+		if i := strings.Index(detail, "INDEX "); i >= 0 {
+			s.Keys = append(s.Keys, table+"."+strings.Fields(detail[i+6:])[0])
+		} else if fields[0] == "SCAN" {
+			s.FullScans = append(s.FullScans, table)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	plan, err := json.Marshal(details)
+	return s, plan, err
+}
+
+func (e *explainer) record(p SlowPlan) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ring[e.next] = p
+	e.next = (e.next + 1) % len(e.ring)
+	if e.next == 0 {
+		e.full = true
+	}
+}
+
+// plans 按时间倒序返回缓冲区中的记录
+func (e *explainer) plans() []SlowPlan {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := e.next
+	if e.full {
+		n = len(e.ring)
+	}
+	out := make([]SlowPlan, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, e.ring[(e.next-i+len(e.ring))%len(e.ring)])
+	}
+	return out
+}
+
+// SlowPlans 返回最近的慢查询执行计划 (新的在前)，未开启 Explain 时返回 nil
+func (c *Client) SlowPlans() []SlowPlan {
+	if c.explainer == nil {
+		return nil
+	}
+	return c.explainer.plans()
+}
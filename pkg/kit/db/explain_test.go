@@ -0,0 +1,84 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExplainBackground(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Driver = "sqlite"
+	cfg.DSN = "file:" + filepath.Join(t.TempDir(), "explain.db")
+	cfg.Metrics = false
+	cfg.LogMode = "warn"
+	cfg.SlowThreshold = time.Nanosecond
+	cfg.Explain = ExplainConfig{Enabled: true, Interval: time.Nanosecond}
+	var buf syncBuffer
+	c, err := NewClient(cfg, slog.New(slog.NewTextHandler(&buf, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	if err := c.GetDB(ctx).AutoMigrate(&tenantDoc{}); err != nil {
+		t.Fatal(err)
+	}
+	var docs []tenantDoc
+	if err := c.GetDB(ctx).Where("name = ?", "a").Find(&docs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var plan *SlowPlan
+	for deadline := time.Now().Add(2 * time.Second); plan == nil && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		for _, p := range c.SlowPlans() {
+			if strings.Contains(p.SQL, "FROM `tenant_docs`") {
+				plan = &p
+				break
+			}
+		}
+	}
+	if plan == nil {
+		t.Fatal("no slow plan recorded")
+	}
+	if plan.Summary == nil || len(plan.Plan) == 0 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	var line string
+	for deadline := time.Now().Add(2 * time.Second); line == "" && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		for l := range strings.Lines(buf.String()) {
+			if strings.Contains(l, "msg=sql_slow_plan") && strings.Contains(l, plan.Digest) {
+				line = l
+			}
+		}
+	}
+	if !strings.Contains(line, "plan.full_scans=[tenant_docs]") || !strings.Contains(line, "plan.rows_examined=") {
+		t.Fatalf("plan summary not logged:\n%s", buf.String())
+	}
+}
+
+// syncBuffer 供日志 worker 与测试并发读写
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
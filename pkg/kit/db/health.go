@@ -0,0 +1,241 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LagProbe 查询从库的复制延迟，非从库或无法判断时返回 0
+type LagProbe func(ctx context.Context, pool gorm.ConnPool) (time.Duration, error)
+
+var (
+	lagProbesMu sync.RWMutex
+	lagProbes   = map[string]LagProbe{
+		"mysql":    mysqlLag,
+		"postgres": postgresLag,
+	}
+)
+
+// RegisterLagProbe 为驱动注册复制延迟探测函数，未注册的驱动只做连通性检查
+func RegisterLagProbe(driver string, probe LagProbe) {
+	lagProbesMu.Lock()
+	defer lagProbesMu.Unlock()
+	lagProbes[driver] = probe
+}
+
+// ReplicaStatus 从库探测状态快照
+type ReplicaStatus struct {
+	Index     int           `json:"index"` // 在 Config.Replicas 中的下标 (DSN 含密码，不对外暴露)
+	Healthy   bool          `json:"healthy"`
+	Lag       time.Duration `json:"lag"`
+	Latency   time.Duration `json:"latency"`
+	Failures  int           `json:"failures"` // 连续失败次数
+	LastError string        `json:"last_error,omitempty"`
+	LastCheck time.Time     `json:"last_check"`
+}
+
+// ReplicaStatus 返回各从库的探测状态，未配置从库时返回 nil
+func (c *Client) ReplicaStatus() []ReplicaStatus {
+	if c.replicas == nil {
+		return nil
+	}
+	out := make([]ReplicaStatus, 0, len(c.replicas.replicas))
+	for _, r := range c.replicas.replicas {
+		r.mu.Lock()
+		out = append(out, ReplicaStatus{
+			Index:     r.index,
+			Healthy:   r.healthy.Load(),
+			Lag:       r.lag,
+			Latency:   time.Duration(r.latency.Load()),
+			Failures:  r.failures,
+			LastError: r.lastErr,
+			LastCheck: r.lastCheck,
+		})
+		r.mu.Unlock()
+	}
+	return out
+}
+
+// prober 后台定时探测从库，连续失败或延迟超限时摘除，恢复后重新加入
+type prober struct {
+	cfg    HealthCheckConfig
+	set    *replicaSet
+	lag    LagProbe
+	logger *SlogAdapter
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newProber(cfg HealthCheckConfig, driver string, set *replicaSet, l *SlogAdapter) *prober {
+	if cfg.Timeout <= 0 || cfg.Timeout > cfg.Interval {
+		cfg.Timeout = cfg.Interval
+	}
+	if cfg.FailThreshold <= 0 {
+		cfg.FailThreshold = 1
+	}
+	lagProbesMu.RLock()
+	lag := lagProbes[driver]
+	lagProbesMu.RUnlock()
+	return &prober{
+		cfg:    cfg,
+		set:    set,
+		lag:    lag,
+		logger: l,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (p *prober) start() {
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			p.probeAll()
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (p *prober) close() {
+	close(p.stop)
+	<-p.done
+}
+
+func (p *prober) probeAll() {
+	var wg sync.WaitGroup
+	for _, r := range p.set.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.probe(r)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *prober) probe(r *replica) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.Timeout)
+	defer cancel()
+
+	begin := time.Now()
+	err := pingPool(ctx, r.pool)
+	elapsed := time.Since(begin)
+
+	var lag time.Duration
+	if err == nil && p.lag != nil {
+		lag, err = p.lag(ctx, r.pool)
+	}
+	if err == nil && p.cfg.MaxLag > 0 && lag > p.cfg.MaxLag {
+		err = fmt.Errorf("replication lag %s exceeds %s", lag, p.cfg.MaxLag)
+	}
+	if err == nil {
+		r.observe(elapsed)
+	}
+
+	r.mu.Lock()
+	r.lag = lag
+	r.mu.Unlock()
+	failures := r.record(err)
+
+	switch {
+	case err != nil && failures >= p.cfg.FailThreshold:
+		if p.set.eject(r) {
+			p.logger.replicaEjected(ctx, r.index, failures, err)
+		}
+	case err == nil:
+		if p.set.restore(r) {
+			p.logger.replicaRestored(ctx, r.index)
+		}
+	}
+}
+
+// record 记录一次探测结果，返回连续失败次数
+func (r *replica) record(err error) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastCheck = time.Now()
+	if err != nil {
+		r.failures++
+		r.lastErr = err.Error()
+	} else {
+		r.failures = 0
+		r.lastErr = ""
+	}
+	return r.failures
+}
+
+func pingPool(ctx context.Context, pool gorm.ConnPool) error {
+	if pinger, ok := pool.(interface{ PingContext(context.Context) error }); ok {
+		return pinger.PingContext(ctx)
+	}
+	return pool.QueryRowContext(ctx, "SELECT 1").Scan(new(int))
+}
+
+// mysqlLag 读取 SHOW REPLICA STATUS 中的 Seconds_Behind_Source，兼容 8.0.22 之前的 SLAVE 语法
+func mysqlLag(ctx context.Context, pool gorm.ConnPool) (time.Duration, error) {
+	rows, err := pool.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		if rows, err = pool.QueryContext(ctx, "SHOW SLAVE STATUS"); err != nil {
+			return 0, err
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		// 不是从库
+		return 0, rows.Err()
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.RawBytes, len(cols))
+	dest := make([]any, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+	for i, col := range cols {
+		if col != "Seconds_Behind_Source" && col != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		sec, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(sec) * time.Second, nil
+	}
+	return 0, nil
+}
+
+// postgresLag 比较 WAL 接收与回放位点，追平时延迟为 0，避免主库空闲时误判
+func postgresLag(ctx context.Context, pool gorm.ConnPool) (time.Duration, error) {
+	const query = `SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+	var sec float64
+	if err := pool.QueryRowContext(ctx, query).Scan(&sec); err != nil {
+		return 0, err
+	}
+	return time.Duration(sec * float64(time.Second)), nil
+}
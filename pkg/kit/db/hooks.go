@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// txScope 保存在 ctx 中的事务作用域，记录事务连接及提交/回滚后的回调
+type txScope struct {
+	db     *gorm.DB
+	parent *txScope // SavePoint 作用域指向外层事务，独立事务为 nil
+
+	mu            sync.Mutex
+	afterCommit   []func()
+	afterRollback []func()
+}
+
+func scopeFrom(ctx context.Context) (*txScope, bool) {
+	s, ok := ctx.Value(txKey{}).(*txScope)
+	return s, ok
+}
+
+// AfterCommit 注册事务提交成功后执行的回调 (如发布事件、清理缓存)，按注册顺序执行。
+// 嵌套作用域的回调会合并到最外层事务，等待其真正提交；ctx 不在事务中时立即执行。
+func AfterCommit(ctx context.Context, fn func()) {
+	s, ok := scopeFrom(ctx)
+	if !ok {
+		fn()
+		return
+	}
+	s.mu.Lock()
+	s.afterCommit = append(s.afterCommit, fn)
+	s.mu.Unlock()
+}
+
+// AfterRollback 注册事务回滚后执行的回调，按注册顺序执行；ctx 不在事务中时忽略
+func AfterRollback(ctx context.Context, fn func()) {
+	s, ok := scopeFrom(ctx)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	s.afterRollback = append(s.afterRollback, fn)
+	s.mu.Unlock()
+}
+
+// complete 根据事务结果触发回调。
+// SavePoint 成功时不执行任何回调，而是并入外层作用域；
+// SavePoint 回滚时其写入已失效，立即执行回滚回调并丢弃提交回调。
+func (s *txScope) complete(committed bool) {
+	s.mu.Lock()
+	commits, rollbacks := s.afterCommit, s.afterRollback
+	s.afterCommit, s.afterRollback = nil, nil
+	s.mu.Unlock()
+
+	if committed && s.parent != nil {
+		s.parent.mu.Lock()
+		s.parent.afterCommit = append(s.parent.afterCommit, commits...)
+		s.parent.afterRollback = append(s.parent.afterRollback, rollbacks...)
+		s.parent.mu.Unlock()
+		return
+	}
+
+	callbacks := rollbacks
+	if committed {
+		callbacks = commits
+	}
+	for _, fn := range callbacks {
+		fn()
+	}
+}
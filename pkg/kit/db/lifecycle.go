@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"goKit/pkg/kit/health"
+
+	"go.uber.org/fx"
+)
+
+// ErrNotReady Client 尚未通过启动检查或已关闭
+var ErrNotReady = errors.New("db: client is not ready")
+
+// StartLifecycle 生命周期管理：启动时 Ping 主库 (失败按配置重试)，不可达的从库只告警，停止时关闭全部连接池。
+// 同时向健康检查注册表登记就绪状态，默认 Client 名为 db，具名 Client 为 db:<name>
+func StartLifecycle(lc fx.Lifecycle, c *Client, reg *health.Registry) {
+	name := "db"
+	if c.name != DefaultName {
+		name += ":" + c.name
+	}
+	reg.Register(name, c.checkReady)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := c.Connect(ctx); err != nil {
+				return err
+			}
+			if c.prober != nil {
+				c.prober.start()
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			reg.Unregister(name)
+			if c.prober != nil {
+				c.prober.close()
+			}
+			return c.Close()
+		},
+	})
+}
+
+// Connect 按 Startup 配置检查连通性 (超时 + 重试)，已就绪时直接返回。
+// 在 StartLifecycle 之前注册、启动时就要访问数据库的钩子 (如迁移) 应先调用
+func (c *Client) Connect(ctx context.Context) error {
+	if c.ready.Load() {
+		return nil
+	}
+	return c.connect(ctx)
+}
+
+// connect 启动时检查连通性，避免错误的 DSN 直到第一次查询才暴露。
+// 只要求主库可用，与 checkReady 一致；从库在主库可用后探测一次，见 checkReplicas
+func (c *Client) connect(ctx context.Context) error {
+	timeout, interval := c.startup.PingTimeout, c.startup.RetryInterval
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for attempt := 1; ; attempt++ {
+		pctx, cancel := context.WithTimeout(ctx, timeout)
+		err := c.pingPrimary(pctx)
+		cancel()
+		if err == nil {
+			c.checkReplicas(ctx, timeout)
+			c.ready.Store(true)
+			return nil
+		}
+		if attempt > c.startup.PingRetries {
+			return err
+		}
+		c.logger.pingFailed(ctx, attempt, err)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// checkReady 就绪检查只要求主库可用，从库异常由健康探测摘除并回退主库
+func (c *Client) checkReady(ctx context.Context) error {
+	if !c.ready.Load() {
+		return ErrNotReady
+	}
+	return c.pingPrimary(ctx)
+}
+
+func (c *Client) pingPrimary(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// checkReplicas 启动时探测各从库一次，不可达的从库只记录日志，不阻止启动。
+// 开启健康探测时先摘除，由探测在恢复后重新加入；未开启时无法恢复，因此不摘除
+func (c *Client) checkReplicas(ctx context.Context, timeout time.Duration) {
+	if c.replicas == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, r := range c.replicas.replicas {
+		wg.Go(func() {
+			pctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			err := pingPool(pctx, r.pool)
+			if err == nil {
+				return
+			}
+			r.record(err)
+			ejected := c.prober != nil && c.replicas.eject(r)
+			c.logger.replicaUnreachable(ctx, r.index, ejected, err)
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConnectReplicaDown(t *testing.T) {
+	tests := []struct {
+		name        string
+		healthCheck time.Duration
+		wantHealthy bool
+	}{
+		{"ejected until prober restores", time.Hour, false},
+		{"kept without prober", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := DefaultConfig()
+			cfg.Driver = "sqlite"
+			cfg.DSN = "file:" + filepath.Join(dir, "primary.db")
+			down := filepath.Join(dir, "down")
+			if err := os.Mkdir(down, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			cfg.Replicas = []string{
+				"file:" + filepath.Join(dir, "replica.db"),
+				"file:" + filepath.Join(down, "replica.db"),
+			}
+			cfg.Metrics = false
+			cfg.MaxIdleConns = -1 // 不保留空闲连接，Ping 时重新建连
+			cfg.HealthCheck.Interval = tt.healthCheck
+			c, err := NewClient(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = c.Close() })
+			// SQLite 建立 Client 时即打开文件，之后移走目录模拟从库不可达
+			if err := os.RemoveAll(down); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := c.Connect(context.Background()); err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			if !c.Ready() {
+				t.Fatal("client not ready")
+			}
+			status := c.ReplicaStatus()
+			if !status[0].Healthy || status[0].Failures != 0 {
+				t.Fatalf("replica 0 = %+v, want healthy", status[0])
+			}
+			if status[1].Healthy != tt.wantHealthy || status[1].Failures != 1 || status[1].LastError == "" {
+				t.Fatalf("replica 1 = %+v, want healthy %v with one failure", status[1], tt.wantHealthy)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"time"
 
 	"gorm.io/gorm/logger"
@@ -14,6 +16,10 @@ type SlogAdapter struct {
 	l             *slog.Logger
 	LogLevel      logger.LogLevel
 	SlowThreshold time.Duration
+	Redact        RedactConfig // 参数脱敏
+	SampleRate    float64      // Info 级别 sql_exec 的采样率，(0, 1) 之外表示全量记录
+
+	dqString bool // 插值后的 SQL 中双引号表示字符串 (MySQL / SQLite)
 }
 
 func NewSlogAdapter(l *slog.Logger, level logger.LogLevel, slow time.Duration) *SlogAdapter {
@@ -46,20 +52,33 @@ func (s *SlogAdapter) Trace(ctx context.Context, begin time.Time, fc func() (str
 		return
 	}
 	elapsed := time.Since(begin)
+	slow := s.SlowThreshold != 0 && elapsed > s.SlowThreshold
+	// 普通语句按采样率记录，错误与慢查询不受影响
+	if err == nil && !slow && s.LogLevel == logger.Info && s.SampleRate > 0 && s.SampleRate < 1 && rand.Float64() >= s.SampleRate {
+		return
+	}
 	sql, rows := fc()
 
 	fields := []any{
 		slog.String("sql", sql),
+		slog.String("digest", digest(sql, s.dqString)),
 		slog.Int64("rows", rows),
 		slog.Duration("lat", elapsed),
 		slog.String("loc", utils.FileWithLineNum()),
 	}
 
+	// 乐观锁冲突属于预期内的并发结果，降级为 Warn
+	if errors.Is(err, ErrStaleObject) {
+		if s.LogLevel >= logger.Warn {
+			s.l.WarnContext(ctx, "sql_stale_object", fields...)
+		}
+		return
+	}
 	if err != nil && s.LogLevel >= logger.Error {
 		s.l.ErrorContext(ctx, "sql_err", append(fields, slog.Any("err", err))...)
 		return
 	}
-	if s.SlowThreshold != 0 && elapsed > s.SlowThreshold && s.LogLevel >= logger.Warn {
+	if slow && s.LogLevel >= logger.Warn {
 		s.l.WarnContext(ctx, "sql_slow", fields...)
 		return
 	}
@@ -67,3 +86,72 @@ func (s *SlogAdapter) Trace(ctx context.Context, begin time.Time, fc func() (str
 		s.l.InfoContext(ctx, "sql_exec", fields...)
 	}
 }
+
+// txRetry 记录事务重试
+func (s *SlogAdapter) txRetry(ctx context.Context, attempt int, delay time.Duration, err error) {
+	if s.LogLevel >= logger.Warn {
+		s.l.WarnContext(ctx, "sql_tx_retry",
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", delay),
+			slog.Any("err", err),
+		)
+	}
+}
+
+// replicaEjected 记录从库被摘除
+func (s *SlogAdapter) replicaEjected(ctx context.Context, index, failures int, err error) {
+	if s.LogLevel >= logger.Warn {
+		s.l.WarnContext(ctx, "sql_replica_ejected",
+			slog.Int("replica", index),
+			slog.Int("failures", failures),
+			slog.Any("err", err),
+		)
+	}
+}
+
+// replicaUnreachable 记录启动时不可达的从库
+func (s *SlogAdapter) replicaUnreachable(ctx context.Context, index int, ejected bool, err error) {
+	if s.LogLevel >= logger.Warn {
+		s.l.WarnContext(ctx, "sql_replica_unreachable",
+			slog.Int("replica", index),
+			slog.Bool("ejected", ejected),
+			slog.Any("err", err),
+		)
+	}
+}
+
+// replicaRestored 记录从库恢复
+func (s *SlogAdapter) replicaRestored(ctx context.Context, index int) {
+	if s.LogLevel >= logger.Warn {
+		s.l.WarnContext(ctx, "sql_replica_restored", slog.Int("replica", index))
+	}
+}
+
+// pingFailed 记录启动 Ping 失败
+func (s *SlogAdapter) pingFailed(ctx context.Context, attempt int, err error) {
+	if s.LogLevel >= logger.Warn {
+		s.l.WarnContext(ctx, "sql_ping_failed",
+			slog.Int("attempt", attempt),
+			slog.Any("err", err),
+		)
+	}
+}
+
+// slowPlan 记录慢查询的执行计划摘要，与同一语句的 sql_slow 通过 digest 关联
+func (s *SlogAdapter) slowPlan(ctx context.Context, p *SlowPlan) {
+	if s.LogLevel >= logger.Warn {
+		s.l.WarnContext(ctx, "sql_slow_plan",
+			slog.String("db", p.DB),
+			slog.String("digest", p.Digest),
+			slog.Duration("lat", p.Duration),
+			slog.Any("plan", p.Summary),
+		)
+	}
+}
+
+// explainFailed 记录慢查询 EXPLAIN 失败
+func (s *SlogAdapter) explainFailed(ctx context.Context, err error) {
+	if s.LogLevel >= logger.Warn {
+		s.l.WarnContext(ctx, "sql_explain_failed", slog.Any("err", err))
+	}
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+const metricsStartKey = "kit:metrics_start"
+
+var (
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kit_db_query_duration_seconds",
+		Help:    "SQL statement latency by operation and table.",
+		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"db", "operation", "table"})
+	queryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kit_db_query_errors_total",
+		Help: "SQL statements that returned an error (record not found excluded).",
+	}, []string{"db", "operation", "table"})
+	rowsAffected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kit_db_rows_affected_total",
+		Help: "Rows affected or returned by SQL statements.",
+	}, []string{"db", "operation", "table"})
+
+	pools = &poolCollector{pools: map[string][]sqlPool{}}
+
+	registerMetrics sync.Once
+)
+
+// metricsPlugin 通过 GORM 回调记录每条语句的耗时、错误与影响行数
+type metricsPlugin struct {
+	name string // Client 名称，对应 db 标签
+}
+
+func newMetricsPlugin(name string) *metricsPlugin {
+	registerMetrics.Do(func() {
+		prometheus.MustRegister(queryDuration, queryErrors, rowsAffected, pools)
+	})
+	return &metricsPlugin{name: name}
+}
+
+func (p *metricsPlugin) Name() string {
+	return "kit:metrics"
+}
+
+func (p *metricsPlugin) Initialize(db *gorm.DB) error {
+	start := func(tx *gorm.DB) {
+		tx.InstanceSet(metricsStartKey, time.Now())
+	}
+	end := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			begin, ok := tx.InstanceGet(metricsStartKey)
+			if !ok {
+				return
+			}
+			table := tx.Statement.Table
+			queryDuration.WithLabelValues(p.name, operation, table).Observe(time.Since(begin.(time.Time)).Seconds())
+			if err := tx.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				queryErrors.WithLabelValues(p.name, operation, table).Inc()
+			}
+			if tx.RowsAffected > 0 {
+				rowsAffected.WithLabelValues(p.name, operation, table).Add(float64(tx.RowsAffected))
+			}
+		}
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("*").Register("kit:metrics_start", start),
+		cb.Create().After("*").Register("kit:metrics_end", end("create")),
+		cb.Query().Before("*").Register("kit:metrics_start", start),
+		cb.Query().After("*").Register("kit:metrics_end", end("query")),
+		cb.Update().Before("*").Register("kit:metrics_start", start),
+		cb.Update().After("*").Register("kit:metrics_end", end("update")),
+		cb.Delete().Before("*").Register("kit:metrics_start", start),
+		cb.Delete().After("*").Register("kit:metrics_end", end("delete")),
+		cb.Row().Before("*").Register("kit:metrics_start", start),
+		cb.Row().After("*").Register("kit:metrics_end", end("row")),
+		cb.Raw().Before("*").Register("kit:metrics_start", start),
+		cb.Raw().After("*").Register("kit:metrics_end", end("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlPool 单个底层连接池
+type sqlPool struct {
+	name string
+	db   *sql.DB
+}
+
+// poolCollector 在采集时读取各 Client 连接池的 sql.DBStats
+type poolCollector struct {
+	mu    sync.RWMutex
+	pools map[string][]sqlPool // key 为 Client 名称
+}
+
+var (
+	poolLabels     = []string{"db", "pool"}
+	poolMaxOpen    = prometheus.NewDesc("kit_db_pool_max_open_connections", "Maximum number of open connections.", poolLabels, nil)
+	poolOpen       = prometheus.NewDesc("kit_db_pool_open_connections", "Established connections, both in use and idle.", poolLabels, nil)
+	poolInUse      = prometheus.NewDesc("kit_db_pool_in_use_connections", "Connections currently in use.", poolLabels, nil)
+	poolIdle       = prometheus.NewDesc("kit_db_pool_idle_connections", "Idle connections.", poolLabels, nil)
+	poolWaitCount  = prometheus.NewDesc("kit_db_pool_wait_count_total", "Connections waited for.", poolLabels, nil)
+	poolWaitTime   = prometheus.NewDesc("kit_db_pool_wait_duration_seconds_total", "Time blocked waiting for a new connection.", poolLabels, nil)
+	poolIdleClosed = prometheus.NewDesc("kit_db_pool_max_idle_closed_total", "Connections closed due to max_idle_conns.", poolLabels, nil)
+	poolLifeClosed = prometheus.NewDesc("kit_db_pool_max_lifetime_closed_total", "Connections closed due to conn_max_lifetime.", poolLabels, nil)
+)
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{poolMaxOpen, poolOpen, poolInUse, poolIdle, poolWaitCount, poolWaitTime, poolIdleClosed, poolLifeClosed} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for name, entries := range c.pools {
+		for _, e := range entries {
+			s := e.db.Stats()
+			gauge := func(d *prometheus.Desc, v float64) {
+				ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, name, e.name)
+			}
+			counter := func(d *prometheus.Desc, v float64) {
+				ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v, name, e.name)
+			}
+			gauge(poolMaxOpen, float64(s.MaxOpenConnections))
+			gauge(poolOpen, float64(s.OpenConnections))
+			gauge(poolInUse, float64(s.InUse))
+			gauge(poolIdle, float64(s.Idle))
+			counter(poolWaitCount, float64(s.WaitCount))
+			counter(poolWaitTime, s.WaitDuration.Seconds())
+			counter(poolIdleClosed, float64(s.MaxIdleClosed))
+			counter(poolLifeClosed, float64(s.MaxLifetimeClosed))
+		}
+	}
+}
+
+// registerPoolMetrics 登记 Client 的连接池，pool 标签为 primary 或 replica-<下标>
+func registerPoolMetrics(name string, entries []sqlPool) {
+	pools.mu.Lock()
+	defer pools.mu.Unlock()
+	pools.pools[name] = entries
+}
+
+func unregisterPoolMetrics(name string) {
+	pools.mu.Lock()
+	defer pools.mu.Unlock()
+	delete(pools.pools, name)
+}
+
+// sqlDBOf 取出 gorm 连接池底层的 *sql.DB
+func sqlDBOf(pool gorm.ConnPool) (*sql.DB, bool) {
+	if p, ok := pool.(*gorm.PreparedStmtDB); ok {
+		pool = p.ConnPool
+	}
+	sqlDB, ok := pool.(*sql.DB)
+	return sqlDB, ok
+}
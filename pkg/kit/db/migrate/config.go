@@ -0,0 +1,19 @@
+package migrate
+
+import "time"
+
+type Config struct {
+	Auto        bool          `mapstructure:"auto"`         // 启动时自动执行 up
+	Dir         string        `mapstructure:"dir"`          // 迁移文件所在目录，create 命令在此生成文件
+	Table       string        `mapstructure:"table"`        // 版本记录表
+	LockTimeout time.Duration `mapstructure:"lock_timeout"` // 等待迁移锁的超时时间
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Auto:        false,
+		Dir:         "migrations",
+		Table:       "schema_migrations",
+		LockTimeout: time.Minute,
+	}
+}
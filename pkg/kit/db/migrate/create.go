@@ -0,0 +1,43 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var nameRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// DriverDir 迁移文件按驱动分目录存放时返回 dir 下该驱动的子目录，否则返回 dir
+func DriverDir(dir, driver string) string {
+	sub := filepath.Join(dir, driver)
+	if info, err := os.Stat(sub); err == nil && info.IsDir() {
+		return sub
+	}
+	return dir
+}
+
+// Create 在 dir 下生成一对空的 up/down 迁移文件，版本号为当前 UTC 时间 (yyyyMMddHHmmss)，返回文件路径
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(nameRe.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("migrate: invalid migration name")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	version := time.Now().UTC().Format("20060102150405")
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %s %s\n", name, direction)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"goKit/pkg/kit/db"
+	"goKit/pkg/kit/log"
+
+	"go.uber.org/fx"
+)
+
+// OnStart 返回一个 fx.Invoke，在 Config.Auto 开启时于启动阶段执行全部未应用的迁移。
+// 需放在 kit.Module 之前，保证迁移先于 HTTP/gRPC 服务启动；执行前按 database.startup 检查连通性，
+// 不依赖 db.StartLifecycle 的顺序。迁移语句不受 database.query_timeout 限制。
+// 迁移受 fx 启动超时约束，使用 StartTimeout 放宽；等待迁移锁最多占用剩余启动时间的一半，超出时返回 ErrLockTimeout
+func OnStart(fsys fs.FS) fx.Option {
+	return fx.Invoke(func(lc fx.Lifecycle, client *db.Client, cfg Config, l *slog.Logger) {
+		if !cfg.Auto {
+			return
+		}
+		m := New(client, fsys, cfg)
+		l = log.NamedFrom(l, "migrate")
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				if deadline, ok := ctx.Deadline(); ok {
+					m.cfg.LockTimeout = min(m.cfg.LockTimeout, time.Until(deadline)/2)
+				}
+				if err := client.Connect(ctx); err != nil {
+					return err
+				}
+				done, err := m.Up(db.WithQueryTimeout(ctx, 0))
+				for _, mg := range done {
+					l.Info("db_migrate_up", slog.Int64("version", mg.Version), slog.String("name", mg.Name))
+				}
+				return err
+			},
+		})
+	})
+}
+
+// StartTimeout 返回开启自动迁移时所需的 fx 启动超时，未开启时返回 base。
+// 启动时等待迁移锁最多占用剩余时间的一半，因此在 base 之上预留两倍 LockTimeout
+func StartTimeout(cfg Config, base time.Duration) time.Duration {
+	if !cfg.Auto {
+		return base
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = DefaultConfig().LockTimeout
+	}
+	return base + 2*cfg.LockTimeout
+}
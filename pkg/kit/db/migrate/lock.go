@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// acquire 在独立连接上获取会话级咨询锁，返回释放函数。
+// MySQL 使用 GET_LOCK，PostgreSQL 使用 pg_advisory_lock，其他驱动 (如 SQLite) 不加锁
+func acquire(ctx context.Context, conn *gorm.DB, name string, timeout time.Duration) (func(), error) {
+	driver := conn.Dialector.Name()
+	if driver != "mysql" && driver != "postgres" {
+		return func() {}, nil
+	}
+
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, err
+	}
+	c, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := "migrate:" + name
+	switch driver {
+	case "mysql":
+		var ok sql.NullInt64
+		err = c.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", key, int(timeout.Seconds())).Scan(&ok)
+		if err == nil && ok.Int64 != 1 {
+			err = ErrLockTimeout
+		}
+		if err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+		return func() {
+			_, _ = c.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", key)
+			_ = c.Close()
+		}, nil
+	default:
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		id := int64(h.Sum64())
+
+		lockCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if _, err = c.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", id); err != nil {
+			_ = c.Close()
+			if errors.Is(err, context.DeadlineExceeded) || lockCtx.Err() != nil {
+				return nil, ErrLockTimeout
+			}
+			return nil, err
+		}
+		return func() {
+			_, _ = c.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", id)
+			_ = c.Close()
+		}, nil
+	}
+}
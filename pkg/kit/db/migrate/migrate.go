@@ -0,0 +1,294 @@
+// Package migrate 基于版本号的 SQL 迁移。
+// 迁移文件命名为 <version>_<name>.up.sql / <version>_<name>.down.sql，通常通过 embed.FS 打包进二进制。
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"goKit/pkg/kit/db"
+
+	"gorm.io/gorm"
+)
+
+var fileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+var (
+	// ErrLockTimeout 等待迁移锁超时
+	ErrLockTimeout = errors.New("migrate: timed out waiting for migration lock")
+	// ErrChecksumMismatch 已执行的 up 文件在执行后被修改
+	ErrChecksumMismatch = errors.New("migrate: applied migration has been modified")
+)
+
+// Migration 单个迁移版本
+type Migration struct {
+	Version int64
+	Name    string
+	up      string // 文件路径
+	down    string
+}
+
+// Status 迁移状态
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// schemaMigration 已执行版本记录表
+type schemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255;not null"`
+	Checksum  string `gorm:"size:64"` // up 文件的 SHA-256，早期版本的记录为空，不做校验
+	AppliedAt time.Time
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	client *db.Client
+	fsys   fs.FS
+	cfg    Config
+}
+
+// New 创建迁移执行器，fsys 根目录下即为迁移文件；
+// 存在以驱动命名的子目录 (mysql、postgres、sqlite、sqlserver) 时使用当前驱动的子目录
+func New(client *db.Client, fsys fs.FS, cfg Config) *Migrator {
+	def := DefaultConfig()
+	if cfg.Table == "" {
+		cfg.Table = def.Table
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = def.LockTimeout
+	}
+	driver := client.GetDB(db.WithAllTenants(context.Background())).Dialector.Name()
+	if info, err := fs.Stat(fsys, driver); err == nil && info.IsDir() {
+		if sub, err := fs.Sub(fsys, driver); err == nil {
+			fsys = sub
+		}
+	}
+	return &Migrator{client: client, fsys: fsys, cfg: cfg}
+}
+
+// Up 执行全部未应用的迁移，返回本次执行的版本
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *gorm.DB) error {
+		migrations, applied, err := m.load(conn)
+		if err != nil {
+			return err
+		}
+		if err = m.verify(migrations, applied); err != nil {
+			return err
+		}
+		for _, mg := range migrations {
+			if _, ok := applied[mg.Version]; ok {
+				continue
+			}
+			if mg.up == "" {
+				return fmt.Errorf("migrate: %d_%s has no up file", mg.Version, mg.Name)
+			}
+			if err = m.apply(conn, mg, mg.up, true); err != nil {
+				return err
+			}
+			done = append(done, mg)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本倒序回滚最近 steps 个已应用的迁移，返回本次回滚的版本
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *gorm.DB) error {
+		migrations, applied, err := m.load(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mg := migrations[i]
+			if _, ok := applied[mg.Version]; !ok {
+				continue
+			}
+			if mg.down == "" {
+				return fmt.Errorf("migrate: %d_%s has no down file", mg.Version, mg.Name)
+			}
+			if err = m.apply(conn, mg, mg.down, false); err != nil {
+				return err
+			}
+			done = append(done, mg)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 返回所有迁移文件及其执行状态，已记录但文件缺失的版本也会列出
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	migrations, applied, err := m.load(conn)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(migrations))
+	for _, mg := range migrations {
+		st := Status{Version: mg.Version, Name: mg.Name}
+		if rec, ok := applied[mg.Version]; ok {
+			st.Applied, st.AppliedAt = true, rec.AppliedAt
+			delete(applied, mg.Version)
+		}
+		out = append(out, st)
+	}
+	for _, rec := range applied {
+		out = append(out, Status{Version: rec.Version, Name: rec.Name, Applied: true, AppliedAt: rec.AppliedAt})
+	}
+	slices.SortFunc(out, func(a, b Status) int { return cmp.Compare(a.Version, b.Version) })
+	return out, nil
+}
+
+// conn 返回直连主库连接池的会话，绕过预编译语句缓存、读写分离与多租户隔离。
+// 会话上的语句都在事务中执行，dbresolver 不会切换事务连接
+func (m *Migrator) conn(ctx context.Context) (*gorm.DB, error) {
+	base := m.client.GetDB(db.WithAllTenants(ctx))
+	sqlDB, err := base.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn := base.Session(&gorm.Session{NewDB: true, Context: ctx})
+	conn.Config.PrepareStmt = false
+	conn.Statement.ConnPool = sqlDB
+	return conn, nil
+}
+
+// locked 持有迁移锁执行 fn，避免多个实例同时迁移
+func (m *Migrator) locked(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	conn, err := m.conn(ctx)
+	if err != nil {
+		return err
+	}
+	unlock, err := acquire(ctx, conn, m.cfg.Table, m.cfg.LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn(conn)
+}
+
+// load 读取迁移文件与已执行记录
+func (m *Migrator) load(conn *gorm.DB) ([]Migration, map[int64]schemaMigration, error) {
+	migrations, err := m.files()
+	if err != nil {
+		return nil, nil, err
+	}
+	var records []schemaMigration
+	err = conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(m.cfg.Table).AutoMigrate(&schemaMigration{}); err != nil {
+			return err
+		}
+		return tx.Table(m.cfg.Table).Find(&records).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	applied := make(map[int64]schemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return migrations, applied, nil
+}
+
+// verify 校验已执行的 up 文件未被修改，修改已执行的迁移应改为新增版本
+func (m *Migrator) verify(migrations []Migration, applied map[int64]schemaMigration) error {
+	for _, mg := range migrations {
+		rec, ok := applied[mg.Version]
+		if !ok || rec.Checksum == "" || mg.up == "" {
+			continue
+		}
+		content, err := fs.ReadFile(m.fsys, mg.up)
+		if err != nil {
+			return err
+		}
+		if sum := checksum(content); sum != rec.Checksum {
+			return fmt.Errorf("%w: %s (checksum %s, applied %s)", ErrChecksumMismatch, mg.up, sum, rec.Checksum)
+		}
+	}
+	return nil
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// files 扫描迁移文件并按版本升序排列
+func (m *Migrator) files() ([]Migration, error) {
+	entries, err := fs.ReadDir(m.fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		match := fileRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: invalid version in %s: %w", e.Name(), err)
+		}
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mg
+		} else if mg.Name != match[2] {
+			return nil, fmt.Errorf("migrate: duplicate version %d (%s, %s)", version, mg.Name, match[2])
+		}
+		if match[3] == "up" {
+			mg.up = e.Name()
+		} else {
+			mg.down = e.Name()
+		}
+	}
+	out := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		out = append(out, *mg)
+	}
+	slices.SortFunc(out, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return out, nil
+}
+
+// apply 在事务中执行迁移文件并更新版本记录
+func (m *Migrator) apply(conn *gorm.DB, mg Migration, file string, up bool) error {
+	content, err := fs.ReadFile(m.fsys, file)
+	if err != nil {
+		return err
+	}
+	err = conn.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range split(string(content)) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			return tx.Table(m.cfg.Table).Create(&schemaMigration{Version: mg.Version, Name: mg.Name, Checksum: checksum(content), AppliedAt: time.Now()}).Error
+		}
+		return tx.Table(m.cfg.Table).Where("version = ?", mg.Version).Delete(&schemaMigration{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migrate: %s: %w", file, err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"goKit/pkg/kit/db"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"single", "SELECT 1", []string{"SELECT 1"}},
+		{"multiple", "SELECT 1;\nSELECT 2;", []string{"SELECT 1", "SELECT 2"}},
+		{"empty statements", " ; ;\n;SELECT 1;;", []string{"SELECT 1"}},
+		{"single quote", "INSERT INTO t VALUES ('a;b');SELECT 1", []string{"INSERT INTO t VALUES ('a;b')", "SELECT 1"}},
+		{"escaped quote", `SELECT 'it\'s;';SELECT 2`, []string{`SELECT 'it\'s;'`, "SELECT 2"}},
+		{"double quote", `SELECT "a;b";SELECT 2`, []string{`SELECT "a;b"`, "SELECT 2"}},
+		{"backtick", "SELECT `a;b`;SELECT 2", []string{"SELECT `a;b`", "SELECT 2"}},
+		{"line comment", "-- drop; things\nSELECT 1; -- trailing;\nSELECT 2", []string{"SELECT 1", "SELECT 2"}},
+		{"block comment", "/* a; b */SELECT 1;/* c */", []string{"SELECT 1"}},
+		{"dollar quote", "CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END $$ LANGUAGE plpgsql;SELECT 1",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END $$ LANGUAGE plpgsql", "SELECT 1"}},
+		{"only comments", "-- nothing\n/* here */", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := split(tt.script); !slices.Equal(got, tt.want) {
+				t.Fatalf("split(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("SELECT 1;")}
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []int64
+		wantErr bool
+	}{
+		{"numeric order", fstest.MapFS{
+			"10_c.up.sql":  file,
+			"2_b.up.sql":   file,
+			"2_b.down.sql": file,
+			"1_a.up.sql":   file,
+			"README.md":    file,
+			"3_x.sql":      file,
+		}, []int64{1, 2, 10}, false},
+		{"timestamp versions", fstest.MapFS{
+			"20240101000002_c.up.sql": file,
+			"20240101000000_a.up.sql": file,
+			"20231231235959_z.up.sql": file,
+		}, []int64{20231231235959, 20240101000000, 20240101000002}, false},
+		{"duplicate version", fstest.MapFS{
+			"1_a.up.sql": file,
+			"1_b.up.sql": file,
+		}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Migrator{fsys: tt.fsys}
+			got, err := m.files()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			var versions []int64
+			for _, mg := range got {
+				versions = append(versions, mg.Version)
+			}
+			if !slices.Equal(versions, tt.want) {
+				t.Fatalf("versions = %v, want %v", versions, tt.want)
+			}
+		})
+	}
+}
+
+func newTestClient(t *testing.T) *db.Client {
+	t.Helper()
+	cfg := db.DefaultConfig()
+	cfg.Driver = "sqlite"
+	cfg.DSN = "file:" + filepath.Join(t.TempDir(), "migrate.db")
+	cfg.Metrics = false
+	c, err := db.NewClient(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(fs fstest.MapFS)
+		wantErr error
+	}{
+		{"unchanged", func(fstest.MapFS) {}, nil},
+		{"new version", func(fs fstest.MapFS) {
+			fs["2_b.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE b (id INTEGER);")}
+		}, nil},
+		{"down file changed", func(fs fstest.MapFS) {
+			fs["1_a.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE IF EXISTS a;")}
+		}, nil},
+		{"applied up file changed", func(fs fstest.MapFS) {
+			fs["1_a.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE a (id INTEGER, name TEXT);")}
+		}, ErrChecksumMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"1_a.up.sql":   &fstest.MapFile{Data: []byte("CREATE TABLE a (id INTEGER);")},
+				"1_a.down.sql": &fstest.MapFile{Data: []byte("DROP TABLE a;")},
+			}
+			client := newTestClient(t)
+			ctx := context.Background()
+			if _, err := New(client, fsys, Config{LockTimeout: time.Second}).Up(ctx); err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(fsys)
+			_, err := New(client, fsys, Config{LockTimeout: time.Second}).Up(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStartTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want time.Duration
+	}{
+		{"manual", Config{LockTimeout: time.Hour}, 15 * time.Second},
+		{"auto", Config{Auto: true, LockTimeout: 30 * time.Second}, 75 * time.Second},
+		{"auto default lock timeout", Config{Auto: true}, 135 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StartTimeout(tt.cfg, 15*time.Second); got != tt.want {
+				t.Fatalf("StartTimeout = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package migrate
+
+import "strings"
+
+// split 按分号拆分多条 SQL，忽略引号、$$ 函数体与注释中的分号，去掉空语句
+func split(script string) []string {
+	var (
+		stmts []string
+		buf   strings.Builder
+		quote byte // 当前所在引号: ' " `
+	)
+	flush := func() {
+		if s := strings.TrimSpace(buf.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		buf.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case quote != 0:
+			buf.WriteByte(ch)
+			if ch == '\\' && quote != '`' && i+1 < len(script) {
+				i++
+				buf.WriteByte(script[i])
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+			buf.WriteByte(ch)
+		case ch == '$' && strings.HasPrefix(script[i:], "$$"):
+			// PostgreSQL 美元符号引用的函数体，原样保留
+			end := strings.Index(script[i+2:], "$$")
+			if end < 0 {
+				end = len(script) - i - 4
+			}
+			buf.WriteString(script[i : i+end+4])
+			i += end + 3
+		case ch == '-' && strings.HasPrefix(script[i:], "--"):
+			// 单行注释，跳到行尾
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+				buf.WriteByte('\n')
+			} else {
+				i = len(script)
+			}
+		case ch == '/' && strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case ch == ';':
+			flush()
+		default:
+			buf.WriteByte(ch)
+		}
+	}
+	flush()
+	return stmts
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"goKit/pkg/kit/health"
+
+	"go.uber.org/fx"
+)
+
+// NamedClients 为 databases 配置中的每个数据源提供一个带 name 标签的 *Client，
+// 各自拥有独立的从库、连接池与日志字段，并在 fx 停止时关闭。默认 Client 仍由 kit.Module 提供。
+//
+//	fx.Provide(fx.Annotate(NewOrderRepo, fx.ParamTags(`name:"orders"`)))
+func NamedClients(cfgs map[string]Config) fx.Option {
+	opts := make([]fx.Option, 0, len(cfgs))
+	for _, name := range slices.Sorted(maps.Keys(cfgs)) {
+		cfg := cfgs[name]
+		opts = append(opts, fx.Provide(fx.Annotate(
+			func(lc fx.Lifecycle, l *slog.Logger, reg *health.Registry) (*Client, error) {
+				c, err := NewNamedClient(name, cfg, l)
+				if err != nil {
+					return nil, fmt.Errorf("db %q: %w", name, err)
+				}
+				StartLifecycle(lc, c, reg)
+				return c, nil
+			},
+			fx.ResultTags(fmt.Sprintf(`name:"%s"`, name)),
+		)))
+	}
+	return fx.Options(opts...)
+}
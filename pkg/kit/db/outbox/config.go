@@ -0,0 +1,51 @@
+package outbox
+
+import "time"
+
+type Config struct {
+	Enabled         bool          `mapstructure:"enabled"`          // 是否在本实例运行 Relay (注入 lock.Locker 时还需当选 leader)
+	PollInterval    time.Duration `mapstructure:"poll_interval"`    // 轮询间隔 (同进程内事务提交会立即唤醒)
+	BatchSize       int           `mapstructure:"batch_size"`       // 每次拉取的事件数
+	MaxAttempts     int           `mapstructure:"max_attempts"`     // 最大投递次数，超过后标记为死信
+	RetryBackoff    time.Duration `mapstructure:"retry_backoff"`    // 首次重试间隔，之后指数增长
+	MaxBackoff      time.Duration `mapstructure:"max_backoff"`      // 重试间隔上限
+	Retention       time.Duration `mapstructure:"retention"`        // 已投递事件保留时长，0 表示不清理
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"` // 清理间隔
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Enabled:         false,
+		PollInterval:    time.Second,
+		BatchSize:       100,
+		MaxAttempts:     10,
+		RetryBackoff:    time.Second,
+		MaxBackoff:      5 * time.Minute,
+		Retention:       7 * 24 * time.Hour,
+		CleanupInterval: time.Hour,
+	}
+}
+
+// withDefaults 未配置的字段使用默认值
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.PollInterval <= 0 {
+		c.PollInterval = def.PollInterval
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = def.BatchSize
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = def.MaxAttempts
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = def.RetryBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = def.MaxBackoff
+	}
+	if c.CleanupInterval <= 0 {
+		c.CleanupInterval = def.CleanupInterval
+	}
+	return c
+}
@@ -0,0 +1,9 @@
+package outbox
+
+import "go.uber.org/fx"
+
+// Module 注册 Relay，需额外 Provide Config 与 Publisher
+var Module = fx.Options(
+	fx.Provide(NewRelay),
+	fx.Invoke(StartLifecycle),
+)
@@ -0,0 +1,79 @@
+// Package outbox 事务发件箱：领域事件与业务数据在同一事务中写入 outbox 表，
+// 由后台 Relay 投递到消息系统，保证 "写库成功 <=> 事件最终发出" (至少一次)。
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"goKit/pkg/kit/db"
+)
+
+// ErrNoTransaction Add 必须在 db.Client.WithTx 内调用
+var ErrNoTransaction = errors.New("outbox: Add must be called inside a transaction")
+
+// Event outbox 表记录
+type Event struct {
+	ID            uint64     `gorm:"primaryKey;autoIncrement;index:idx_outbox_events_key_id,priority:2" json:"id"`
+	Topic         string     `gorm:"size:128;not null" json:"topic"`
+	Key           string     `gorm:"size:128;not null;default:'';index:idx_outbox_events_key_id,priority:1" json:"key"` // 聚合键，同一 Key 的事件按写入顺序投递
+	Payload       []byte     `json:"payload"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null" json:"next_attempt_at"`
+	LastError     string     `gorm:"size:512" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `gorm:"index" json:"delivered_at,omitempty"`
+	DeadAt        *time.Time `json:"dead_at,omitempty"` // 超过最大重试次数后不再投递
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (Event) TableName() string {
+	return "outbox_events"
+}
+
+// Publisher 消息投递接口，由业务方实现 (Kafka / RabbitMQ / NATS ...)
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// wakeup 事务提交后唤醒 Relay，缩短投递延迟
+var wakeup = make(chan struct{}, 1)
+
+func notify() {
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// NewEvent 构造事件，payload 序列化为 JSON
+func NewEvent(topic, key string, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Topic: topic, Key: key, Payload: data}, nil
+}
+
+// Add 通过 ctx 绑定的事务写入事件，与业务数据同时提交或回滚
+func Add(ctx context.Context, events ...*Event) error {
+	tx, ok := db.TxFromContext(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, e := range events {
+		if e.NextAttemptAt.IsZero() {
+			e.NextAttemptAt = now
+		}
+	}
+	if err := tx.Create(events).Error; err != nil {
+		return err
+	}
+	db.AfterCommit(ctx, notify)
+	return nil
+}
@@ -0,0 +1,231 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"goKit/pkg/kit/db"
+	"goKit/pkg/kit/lock"
+	"goKit/pkg/kit/log"
+
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Relay 后台轮询 outbox 表并投递事件。
+// 同一 Key 的事件严格按 ID 顺序投递，前一条未成功时后续事件会等待；
+// 多实例部署时应注入 *lock.Locker，由选主保证只有一个实例投递，否则可能重复投递。
+type Relay struct {
+	client *db.Client
+	pub    Publisher
+	cfg    Config
+	l      *slog.Logger
+	locker *lock.Locker
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// RelayParams 注入参数
+type RelayParams struct {
+	fx.In
+
+	Client    *db.Client
+	Publisher Publisher
+	Config    Config
+	Logger    *slog.Logger
+	Locker    *lock.Locker `optional:"true"`
+}
+
+func NewRelay(params RelayParams) *Relay {
+	return &Relay{
+		client: params.Client,
+		pub:    params.Publisher,
+		cfg:    params.Config.withDefaults(),
+		l:      log.NamedFrom(params.Logger, "outbox"),
+		locker: params.Locker,
+		done:   make(chan struct{}),
+	}
+}
+
+// StartLifecycle 生命周期管理，注入了 *lock.Locker 时仅在选主成功的实例上运行
+func StartLifecycle(lc fx.Lifecycle, r *Relay) {
+	if !r.cfg.Enabled {
+		return
+	}
+	if r.locker != nil {
+		lock.StartElection(lc, r.locker.NewElection("outbox_relay", lock.Callbacks{
+			OnElected: func(ctx context.Context) {
+				r.l.Info("outbox_relay_start")
+				r.run(ctx)
+			},
+			OnRevoked: func() {
+				r.l.Info("outbox_relay_stop")
+			},
+		}))
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			r.l.Info("outbox_relay_start")
+			runCtx, cancel := context.WithCancel(context.Background())
+			r.cancel = cancel
+			go func() {
+				defer close(r.done)
+				r.run(runCtx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			r.cancel()
+			select {
+			case <-r.done:
+			case <-ctx.Done():
+			}
+			r.l.Info("outbox_relay_stop")
+			return nil
+		},
+	})
+}
+
+func (r *Relay) run(ctx context.Context) {
+	poll := time.NewTicker(r.cfg.PollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(r.cfg.CleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		for r.dispatch(ctx) {
+			// 整批都投递成功说明可能还有积压 (包括同一 Key 的后续事件)，继续拉取
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-wakeup:
+		case <-cleanup.C:
+			r.cleanup(ctx)
+		}
+	}
+}
+
+// db 读写都强制走主库，避免从库延迟导致重复投递；Relay 跨租户投递
+func (r *Relay) db(ctx context.Context) *gorm.DB {
+	return r.client.GetDB(db.WithAllTenants(db.WithPrimary(ctx)))
+}
+
+// dispatch 投递一批待发送事件，返回是否需要立即拉取下一批。
+// 只拉取已到重试时间、且同一 Key 没有更早的未投递事件的记录，
+// 避免某个 Key 的积压或退避中的事件占满批次，导致其他事件一直得不到投递
+func (r *Relay) dispatch(ctx context.Context) bool {
+	var events []*Event
+	key := clause.Column{Table: clause.CurrentTable, Name: "key"}
+	err := r.db(ctx).
+		Where("delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", time.Now()).
+		Where("? = '' OR NOT EXISTS (SELECT 1 FROM ? p WHERE ? = ? AND p.id < ? AND p.delivered_at IS NULL AND p.dead_at IS NULL)",
+			key, clause.Table{Name: Event{}.TableName()}, clause.Column{Table: "p", Name: "key"}, key,
+			clause.Column{Table: clause.CurrentTable, Name: "id"}).
+		Order("id").
+		Limit(r.cfg.BatchSize).
+		Find(&events).Error
+	if err != nil {
+		if ctx.Err() == nil {
+			r.l.ErrorContext(ctx, "outbox_fetch_failed", slog.Any("err", err))
+		}
+		return false
+	}
+
+	// 每个 Key 在一批中至多出现一条，失败的事件退避后才会再次拉取，同 Key 的后续事件随之等待
+	delivered := 0
+	for _, e := range events {
+		if ctx.Err() != nil {
+			return false
+		}
+		if err := r.pub.Publish(ctx, e); err != nil {
+			r.fail(ctx, e, err)
+			continue
+		}
+		if err := r.db(ctx).Model(e).Update("delivered_at", time.Now()).Error; err != nil {
+			r.l.ErrorContext(ctx, "outbox_mark_delivered_failed", slog.Uint64("id", e.ID), slog.Any("err", err))
+			return false
+		}
+		delivered++
+	}
+	// 同一 Key 的后续事件要等前一条投递后才会被拉取，因此整批成功时总是继续拉取
+	return len(events) > 0 && delivered == len(events)
+}
+
+// fail 记录失败并按指数退避安排下次投递，超过最大次数后标记为死信
+func (r *Relay) fail(ctx context.Context, e *Event, cause error) {
+	attempts := e.Attempts + 1
+	msg := cause.Error()
+	if len(msg) > 512 {
+		msg = msg[:512]
+	}
+	updates := map[string]any{"attempts": attempts, "last_error": msg}
+
+	if attempts >= r.cfg.MaxAttempts {
+		updates["dead_at"] = time.Now()
+		r.l.ErrorContext(ctx, "outbox_event_dead",
+			slog.Uint64("id", e.ID),
+			slog.String("topic", e.Topic),
+			slog.String("key", e.Key),
+			slog.Int("attempts", attempts),
+			slog.Any("err", cause),
+		)
+	} else {
+		backoff := r.cfg.RetryBackoff << (attempts - 1)
+		if backoff <= 0 || backoff > r.cfg.MaxBackoff {
+			backoff = r.cfg.MaxBackoff
+		}
+		updates["next_attempt_at"] = time.Now().Add(backoff)
+		r.l.WarnContext(ctx, "outbox_publish_failed",
+			slog.Uint64("id", e.ID),
+			slog.String("topic", e.Topic),
+			slog.Int("attempts", attempts),
+			slog.Duration("backoff", backoff),
+			slog.Any("err", cause),
+		)
+	}
+	if err := r.db(ctx).Model(e).Updates(updates).Error; err != nil {
+		r.l.ErrorContext(ctx, "outbox_mark_failed_failed", slog.Uint64("id", e.ID), slog.Any("err", err))
+	}
+}
+
+// cleanup 删除超过保留期的已投递事件
+func (r *Relay) cleanup(ctx context.Context) {
+	if r.cfg.Retention <= 0 {
+		return
+	}
+	res := r.db(ctx).
+		Where("delivered_at IS NOT NULL AND delivered_at < ?", time.Now().Add(-r.cfg.Retention)).
+		Delete(&Event{})
+	if res.Error != nil {
+		r.l.ErrorContext(ctx, "outbox_cleanup_failed", slog.Any("err", res.Error))
+		return
+	}
+	if res.RowsAffected > 0 {
+		r.l.Info("outbox_cleanup", slog.Int64("rows", res.RowsAffected))
+	}
+}
+
+// LogPublisher 仅打印事件的 Publisher，用于本地开发或尚未接入消息系统时
+type LogPublisher struct {
+	l *slog.Logger
+}
+
+func NewLogPublisher(l *slog.Logger) Publisher {
+	return &LogPublisher{l: log.NamedFrom(l, "outbox")}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, e *Event) error {
+	p.l.InfoContext(ctx, "outbox_event",
+		slog.Uint64("id", e.ID),
+		slog.String("topic", e.Topic),
+		slog.String("key", e.Key),
+		slog.String("payload", string(e.Payload)),
+	)
+	return nil
+}
@@ -0,0 +1,110 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"goKit/pkg/kit/db"
+)
+
+// recordPublisher 记录投递顺序，poison 中的 Key 总是投递失败
+type recordPublisher struct {
+	mu     sync.Mutex
+	poison map[string]bool
+	got    []string
+}
+
+func (p *recordPublisher) Publish(_ context.Context, e *Event) error {
+	if p.poison[e.Key] {
+		return errors.New("broker rejected")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.got = append(p.got, e.Topic)
+	return nil
+}
+
+func newTestRelay(t *testing.T, pub Publisher, batch int) (*Relay, *db.Client) {
+	t.Helper()
+	cfg := db.DefaultConfig()
+	cfg.Driver = "sqlite"
+	cfg.DSN = "file:" + filepath.Join(t.TempDir(), "outbox.db")
+	cfg.Metrics = false
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client, err := db.NewClient(cfg, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	if err := client.GetDB(context.Background()).AutoMigrate(&Event{}); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRelay(RelayParams{
+		Client:    client,
+		Publisher: pub,
+		Config:    Config{BatchSize: batch, RetryBackoff: time.Hour},
+		Logger:    l,
+	})
+	return r, client
+}
+
+func TestDispatch(t *testing.T) {
+	type ev struct{ topic, key string }
+	tests := []struct {
+		name   string
+		batch  int
+		events []ev
+		poison []string
+		want   []string
+	}{
+		{
+			name:   "poisoned key does not starve others",
+			batch:  2,
+			events: []ev{{"bad1", "bad"}, {"bad2", "bad"}, {"bad3", "bad"}, {"a1", "a"}, {"n1", ""}},
+			poison: []string{"bad"},
+			want:   []string{"a1", "n1"},
+		},
+		{
+			name:   "same key in order across batches",
+			batch:  2,
+			events: []ev{{"a1", "a"}, {"a2", "a"}, {"b1", "b"}, {"a3", "a"}, {"b2", "b"}},
+			want:   []string{"a1", "b1", "a2", "b2", "a3"},
+		},
+		{
+			name:   "empty key is never blocked",
+			batch:  1,
+			events: []ev{{"bad1", "bad"}, {"n1", ""}, {"n2", ""}},
+			poison: []string{"bad"},
+			want:   []string{"n1", "n2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &recordPublisher{poison: map[string]bool{}}
+			for _, k := range tt.poison {
+				pub.poison[k] = true
+			}
+			r, client := newTestRelay(t, pub, tt.batch)
+			ctx := context.Background()
+			for _, e := range tt.events {
+				event := &Event{Topic: e.topic, Key: e.key, NextAttemptAt: time.Now().Add(-time.Second)}
+				if err := client.GetDB(ctx).Create(event).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			for range 10 {
+				r.dispatch(ctx)
+			}
+			if !slices.Equal(pub.got, tt.want) {
+				t.Fatalf("delivered %v, want %v", pub.got, tt.want)
+			}
+		})
+	}
+}
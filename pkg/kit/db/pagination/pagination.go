@@ -0,0 +1,211 @@
+// Package pagination 基于键集 (Keyset) 的游标分页，翻到多深都只扫描 page_size 行。
+// 游标编码为带 HMAC 签名的 base64 token，可直接映射到 HTTP 查询参数或 gRPC 的 page_token / next_page_token 字段。
+package pagination
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	macSize = 16
+)
+
+// ErrInvalidToken page_token 格式错误、签名不匹配或与排序列不一致
+var ErrInvalidToken = errors.New("pagination: invalid page token")
+
+// Column 排序列，按声明顺序组成排序键
+type Column struct {
+	Name string // 数据库列名或结构体字段名
+	Desc bool
+}
+
+// Request 分页请求，可直接用 fiber 的 c.QueryParser 绑定
+type Request struct {
+	PageToken string `query:"page_token" json:"page_token"`
+	PageSize  int    `query:"page_size" json:"page_size"`
+}
+
+// ProtoRequest 兼容 protoc 生成的 List 请求 (page_token / page_size 字段)
+type ProtoRequest interface {
+	GetPageToken() string
+	GetPageSize() int32
+}
+
+// FromProto 从 gRPC 请求构造分页请求
+func FromProto(req ProtoRequest) Request {
+	return Request{PageToken: req.GetPageToken(), PageSize: int(req.GetPageSize())}
+}
+
+// Page 分页结果，NextPageToken 为空表示没有下一页。
+// 作为 response.Success 的 data 返回，或将 NextPageToken 赋给 gRPC 响应的 next_page_token
+type Page[T any] struct {
+	Items         []*T   `json:"items"`
+	NextPageToken string `json:"next_page_token,omitempty"`
+	HasMore       bool   `json:"has_more"`
+}
+
+// Paginator 键集分页器，一个列表接口对应一个实例。
+// 最后一列必须唯一 (通常是主键) 且所有排序列不可为 NULL，以保证排序稳定
+type Paginator struct {
+	secret  []byte
+	columns []Column
+	key     string // 排序列指纹，防止 token 被用于其他列表
+}
+
+// New 创建分页器，secret 用于签名 token
+func New(secret []byte, columns ...Column) *Paginator {
+	if len(secret) == 0 {
+		panic("pagination: secret is empty")
+	}
+	if len(columns) == 0 {
+		panic("pagination: at least one column is required")
+	}
+	parts := make([]string, len(columns))
+	for i, c := range columns {
+		parts[i] = c.Name
+		if c.Desc {
+			parts[i] += " desc"
+		}
+	}
+	return &Paginator{secret: secret, columns: columns, key: strings.Join(parts, ",")}
+}
+
+// Paginate 在 tx 的查询条件上执行键集分页，排序由分页器决定，tx 上不应再设置 Order
+func Paginate[T any](tx *gorm.DB, p *Paginator, req Request) (*Page[T], error) {
+	fields, err := p.fields(tx, new(T))
+	if err != nil {
+		return nil, err
+	}
+
+	size := req.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	if size > MaxPageSize {
+		size = MaxPageSize
+	}
+
+	tx = tx.Model(new(T))
+	if req.PageToken != "" {
+		values, err := p.decode(req.PageToken, fields)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(p.seek(fields, values))
+	}
+	for i, f := range fields {
+		tx = tx.Order(clause.OrderByColumn{Column: column(f), Desc: p.columns[i].Desc})
+	}
+
+	var items []*T
+	if err = tx.Limit(size + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{Items: items}
+	if len(items) > size {
+		page.Items, page.HasMore = items[:size], true
+		if page.NextPageToken, err = p.encode(tx.Statement.Context, fields, page.Items[size-1]); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func (p *Paginator) fields(tx *gorm.DB, model any) ([]*schema.Field, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	fields := make([]*schema.Field, len(p.columns))
+	for i, c := range p.columns {
+		if fields[i] = stmt.Schema.LookUpField(c.Name); fields[i] == nil {
+			return nil, fmt.Errorf("pagination: unknown column %q", c.Name)
+		}
+	}
+	return fields, nil
+}
+
+// seek 构造 (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ... 形式的条件，支持各列方向不同
+func (p *Paginator) seek(fields []*schema.Field, values []any) clause.Expression {
+	ors := make([]clause.Expression, 0, len(fields))
+	for i, f := range fields {
+		ands := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, clause.Eq{Column: column(fields[j]), Value: values[j]})
+		}
+		if p.columns[i].Desc {
+			ands = append(ands, clause.Lt{Column: column(f), Value: values[i]})
+		} else {
+			ands = append(ands, clause.Gt{Column: column(f), Value: values[i]})
+		}
+		ors = append(ors, clause.And(ands...))
+	}
+	return clause.Or(ors...)
+}
+
+// encode token = base64url(HMAC[:16] || json(values))
+func (p *Paginator) encode(ctx context.Context, fields []*schema.Field, last any) (string, error) {
+	rv := reflect.ValueOf(last)
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		values[i], _ = f.ValueOf(ctx, rv)
+	}
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(p.sign(payload), payload...)), nil
+}
+
+// decode 校验签名并按字段类型还原排序键
+func (p *Paginator) decode(token string, fields []*schema.Field) ([]any, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) <= macSize {
+		return nil, ErrInvalidToken
+	}
+	mac, payload := raw[:macSize], raw[macSize:]
+	if !hmac.Equal(mac, p.sign(payload)) {
+		return nil, ErrInvalidToken
+	}
+
+	var parts []json.RawMessage
+	if err = json.Unmarshal(payload, &parts); err != nil || len(parts) != len(fields) {
+		return nil, ErrInvalidToken
+	}
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		v := reflect.New(f.FieldType)
+		if err = json.Unmarshal(parts[i], v.Interface()); err != nil {
+			return nil, ErrInvalidToken
+		}
+		values[i] = v.Elem().Interface()
+	}
+	return values, nil
+}
+
+func (p *Paginator) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, p.secret)
+	h.Write([]byte(p.key))
+	h.Write(payload)
+	return h.Sum(nil)[:macSize]
+}
+
+func column(f *schema.Field) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: f.DBName}
+}
@@ -0,0 +1,169 @@
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+type item struct {
+	ID        int64 `gorm:"primaryKey"`
+	Score     int
+	CreatedAt time.Time
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "page.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func mustFields(t *testing.T, db *gorm.DB, p *Paginator) []*schema.Field {
+	t.Helper()
+	fields, err := p.fields(db, new(item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fields
+}
+
+func TestSeek(t *testing.T) {
+	db := openDB(t)
+	tests := []struct {
+		name    string
+		columns []Column
+		values  []any
+		want    string
+	}{
+		{"single asc", []Column{{Name: "ID"}}, []any{int64(5)},
+			"SELECT * FROM `items` WHERE `items`.`id` > ?"},
+		{"single desc", []Column{{Name: "id", Desc: true}}, []any{int64(5)},
+			"SELECT * FROM `items` WHERE `items`.`id` < ?"},
+		{"mixed directions", []Column{{Name: "Score", Desc: true}, {Name: "ID"}}, []any{3, int64(5)},
+			"SELECT * FROM `items` WHERE (`items`.`score` < ? OR (`items`.`score` = ? AND `items`.`id` > ?))"},
+		{"three columns", []Column{{Name: "Score"}, {Name: "CreatedAt"}, {Name: "ID"}}, []any{3, time.Time{}, int64(5)},
+			"SELECT * FROM `items` WHERE (`items`.`score` > ? OR (`items`.`score` = ? AND `items`.`created_at` > ?) OR (`items`.`score` = ? AND `items`.`created_at` = ? AND `items`.`id` > ?))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New([]byte("secret"), tt.columns...)
+			fields := mustFields(t, db, p)
+			stmt := db.Session(&gorm.Session{DryRun: true}).Model(&item{}).Where(p.seek(fields, tt.values)).Find(&[]item{}).Statement
+			if got := stmt.SQL.String(); got != tt.want {
+				t.Fatalf("seek SQL\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	db := openDB(t)
+	p := New([]byte("secret"), Column{Name: "Score", Desc: true}, Column{Name: "ID"})
+	fields := mustFields(t, db, p)
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	token, err := p.encode(context.Background(), fields, &item{ID: 7, Score: 42, CreatedAt: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(token)
+	tampered := slices.Clone(raw)
+	tampered[len(tampered)-2] ^= 1
+
+	tests := []struct {
+		name  string
+		p     *Paginator
+		token string
+		want  []any
+	}{
+		{"round trip", p, token, []any{42, int64(7)}},
+		{"other secret", New([]byte("other"), p.columns...), token, nil},
+		{"other columns", New([]byte("secret"), Column{Name: "Score"}, Column{Name: "ID"}), token, nil},
+		{"tampered payload", p, base64.RawURLEncoding.EncodeToString(tampered), nil},
+		{"not base64", p, "!!!", nil},
+		{"too short", p, base64.RawURLEncoding.EncodeToString(raw[:macSize]), nil},
+		{"empty", p, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.p.decode(tt.token, fields)
+			if tt.want == nil {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("err = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("decode = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	db := openDB(t)
+	scores := []int{3, 1, 3, 2, 1, 3, 2}
+	for i, s := range scores {
+		if err := db.Create(&item{ID: int64(i + 1), Score: s}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name    string
+		columns []Column
+		size    int
+		want    []int64
+	}{
+		{"id asc", []Column{{Name: "ID"}}, 3, []int64{1, 2, 3, 4, 5, 6, 7}},
+		{"id desc", []Column{{Name: "ID", Desc: true}}, 2, []int64{7, 6, 5, 4, 3, 2, 1}},
+		{"score desc id asc", []Column{{Name: "Score", Desc: true}, {Name: "ID"}}, 2, []int64{1, 3, 6, 4, 7, 2, 5}},
+		{"score asc id desc", []Column{{Name: "Score"}, {Name: "ID", Desc: true}}, 3, []int64{5, 2, 7, 4, 6, 3, 1}},
+		{"exact page", []Column{{Name: "ID"}}, 7, []int64{1, 2, 3, 4, 5, 6, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New([]byte("secret"), tt.columns...)
+			var got []int64
+			req := Request{PageSize: tt.size}
+			for pages := 0; ; pages++ {
+				if pages > len(scores) {
+					t.Fatal("pagination does not terminate")
+				}
+				page, err := Paginate[item](db, p, req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, it := range page.Items {
+					got = append(got, it.ID)
+				}
+				if page.HasMore != (page.NextPageToken != "") {
+					t.Fatalf("HasMore = %v with token %q", page.HasMore, page.NextPageToken)
+				}
+				if !page.HasMore {
+					break
+				}
+				req.PageToken = page.NextPageToken
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package db
+
+import (
+	"context"
+	"hash/fnv"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// redactedValue 脱敏后写入日志的占位值
+const redactedValue = "******"
+
+// RedactConfig SQL 日志脱敏配置，列名匹配不区分大小写，支持 * 通配 (如 *_token)。
+// Allow 为空时为黑名单模式：仅遮盖命中 Deny 的列；
+// Allow 非空时为白名单模式：仅展示命中 Allow 且未命中 Deny 的列。
+// 两种模式下无法识别列名的参数 (如函数参数、Raw SQL 中的复杂表达式) 一律遮盖
+type RedactConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Allow   []string `mapstructure:"allow"`
+	Deny    []string `mapstructure:"deny"`
+}
+
+func (r RedactConfig) visible(column string) bool {
+	if column == "" || matchColumn(r.Deny, column) {
+		return false
+	}
+	if len(r.Allow) > 0 {
+		return matchColumn(r.Allow, column)
+	}
+	return true
+}
+
+func matchColumn(patterns []string, column string) bool {
+	if column == "" {
+		return false
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), column); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter，在 SQL 插值写入日志前按列名遮盖参数
+func (s *SlogAdapter) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if !s.Redact.Enabled || len(params) == 0 {
+		return sql, params
+	}
+	// pgx 允许首个参数为 QueryExecMode，此时 $n 对应 params[n]
+	offset := 0
+	if _, ok := params[0].(pgx.QueryExecMode); ok {
+		offset = 1
+	}
+	columns := paramColumns(tokenize(sql, s.dqString), len(params)-offset)
+
+	masked := make([]any, len(params))
+	copy(masked, params)
+	for i := offset; i < len(masked); i++ {
+		if masked[i] == nil || s.Redact.visible(columns[i-offset]) {
+			continue
+		}
+		masked[i] = redactedValue
+	}
+	return sql, masked
+}
+
+type tokenKind int
+
+const (
+	tokIdent  tokenKind = iota // 未加引号的标识符或关键字
+	tokQuoted                  // 加引号的标识符
+	tokString                  // 字符串字面量
+	tokNumber                  // 数字字面量
+	tokParam                   // 占位符 ? / $n / @pn
+	tokPunct                   // 运算符与标点
+)
+
+type token struct {
+	kind tokenKind
+	text string // tokQuoted 为去掉引号后的内容，tokParam 为 $ / @p 后的序号 (? 为空)
+}
+
+// tokenize 简易 SQL 词法分析，跳过空白与注释。
+// dqString 为 true 时双引号表示字符串 (MySQL / SQLite 日志插值)，否则表示标识符
+func tokenize(sql string, dqString bool) []token {
+	var toks []token
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+		case c == '\'' || (c == '"' && dqString):
+			i = skipQuoted(sql, i)
+			toks = append(toks, token{kind: tokString})
+		case c == '"' || c == '`':
+			end := skipQuoted(sql, i)
+			toks = append(toks, token{kind: tokQuoted, text: strings.ToLower(sql[i+1 : max(end-1, i+1)])})
+			i = end
+		case c == '?':
+			toks = append(toks, token{kind: tokParam})
+			i++
+		case (c == '$' || c == '@') && i+1 < len(sql):
+			j := i + 1
+			if c == '@' && (sql[j] == 'p' || sql[j] == 'P') {
+				j++
+			}
+			k := j
+			for k < len(sql) && isDigit(sql[k]) {
+				k++
+			}
+			if k > j {
+				toks = append(toks, token{kind: tokParam, text: sql[j:k]})
+				i = k
+			} else {
+				toks = append(toks, token{kind: tokPunct, text: string(c)})
+				i++
+			}
+		case isDigit(c) || (c == '.' && i+1 < len(sql) && isDigit(sql[i+1])):
+			j := i
+			for j < len(sql) && (isDigit(sql[j]) || sql[j] == '.' || sql[j] == 'e' || sql[j] == 'E' || sql[j] == 'x' || isHex(sql[j])) {
+				j++
+			}
+			toks = append(toks, token{kind: tokNumber})
+			i = j
+		case isIdentStart(c):
+			j := i
+			for j < len(sql) && (isIdentStart(sql[j]) || isDigit(sql[j]) || sql[j] == '$') {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: sql[i:j]})
+			i = j
+		default:
+			toks = append(toks, token{kind: tokPunct, text: string(c)})
+			i++
+		}
+	}
+	return toks
+}
+
+// skipQuoted 跳过以 sql[i] 开头的引号内容，支持双写引号与反斜杠转义，返回结束引号之后的位置
+func skipQuoted(sql string, i int) int {
+	q := sql[i]
+	for j := i + 1; j < len(sql); j++ {
+		switch sql[j] {
+		case '\\':
+			if q != '`' {
+				j++
+			}
+		case q:
+			if j+1 < len(sql) && sql[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(sql)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isHex(c byte) bool { return (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// 出现后重置当前列名的关键字
+var resetKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "ON": true,
+	"HAVING": true, "ORDER": true, "GROUP": true, "BY": true, "LIMIT": true, "OFFSET": true,
+	"CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true, "RETURNING": true,
+	"SET": true, "UPDATE": true, "INTO": true, "JOIN": true, "FETCH": true,
+}
+
+// 不改变当前列名的关键字 (col NOT IN ?, col IS NOT NULL ...)
+var keepKeywords = map[string]bool{
+	"IN": true, "NOT": true, "LIKE": true, "ILIKE": true, "IS": true, "NULL": true,
+	"ESCAPE": true, "ANY": true, "ALL": true, "SOME": true, "COLLATE": true, "BINARY": true,
+}
+
+// paramColumns 推断每个占位符对应的列名 (小写，无法识别时为空)，下标与参数下标一致。
+// 识别 col = ?、col IN (?, ?)、col BETWEEN ? AND ?、SET col = ? 与 INSERT (cols) VALUES (...) 等常见形式；
+// 后跟 ( 的标识符视为函数名而非列名：col = LOWER(?) 归属 col，SELECT COALESCE(?, ...) 无法识别
+func paramColumns(toks []token, n int) []string {
+	columns := make([]string, n)
+	var (
+		ordinal     int    // ? 占位符的出现顺序
+		current     string // 最近出现的列名
+		betweenCol  string
+		insert      bool
+		insertCols  []string
+		collecting  bool // 正在读取 INSERT 的列清单
+		colsDone    bool
+		values      bool // 处于 VALUES 元组中
+		depth, slot int
+	)
+	for i, t := range toks {
+		call := i+1 < len(toks) && toks[i+1].kind == tokPunct && toks[i+1].text == "("
+		switch t.kind {
+		case tokIdent:
+			kw := strings.ToUpper(t.text)
+			switch {
+			case kw == "INSERT":
+				insert = true
+			case kw == "VALUES" && insert:
+				values, depth = true, 0
+			case kw == "BETWEEN":
+				betweenCol = current
+			case kw == "AND" && betweenCol != "":
+				current, betweenCol = betweenCol, ""
+			case resetKeywords[kw]:
+				current = ""
+				if kw == "ON" || kw == "RETURNING" {
+					values = false
+				}
+			case keepKeywords[kw]:
+			case collecting:
+				insertCols = append(insertCols, strings.ToLower(t.text))
+			case call:
+				// 函数名 (或 INSERT INTO t (...) 的表名)，保留当前列名
+			default:
+				current = strings.ToLower(t.text)
+			}
+		case tokQuoted:
+			if collecting {
+				insertCols = append(insertCols, t.text)
+			} else if !call {
+				current = t.text
+			}
+		case tokPunct:
+			switch t.text {
+			case "(":
+				if insert && !colsDone && !values {
+					collecting = true
+				}
+				if values {
+					if depth == 0 {
+						slot = 0
+					}
+					depth++
+				}
+			case ")":
+				if collecting {
+					collecting, colsDone = false, true
+				}
+				if values {
+					depth--
+				}
+			case ",":
+				if values && depth == 1 {
+					slot++
+				}
+			}
+		case tokParam:
+			idx := ordinal
+			if t.text != "" {
+				idx, _ = strconv.Atoi(t.text)
+				idx--
+			} else {
+				ordinal++
+			}
+			if idx < 0 || idx >= n {
+				continue
+			}
+			if values && depth >= 1 && slot < len(insertCols) {
+				// 嵌套的函数调用 (如 VALUES (?, LOWER(?))) 仍归属当前列
+				columns[idx] = insertCols[slot]
+			} else if !values {
+				columns[idx] = current
+			}
+		}
+	}
+	return columns
+}
+
+// fingerprint 归一化 SQL：字面量与占位符替换为 ?，IN 列表与多行 VALUES 折叠，标识符转小写
+func fingerprint(sql string, dqString bool) string {
+	toks := tokenize(sql, dqString)
+	parts := make([]string, 0, len(toks))
+	for _, t := range toks {
+		switch t.kind {
+		case tokString, tokNumber, tokParam:
+			parts = append(parts, "?")
+		case tokIdent, tokQuoted:
+			parts = append(parts, strings.ToLower(t.text))
+		default:
+			parts = append(parts, t.text)
+		}
+	}
+
+	// (?, ?, ?) -> (?+)，随后 (?+), (?+) -> (?+)
+	out := make([]string, 0, len(parts))
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "(" {
+			j := i + 1
+			for j+1 < len(parts) && parts[j] == "?" && parts[j+1] == "," {
+				j += 2
+			}
+			if j+1 < len(parts) && parts[j] == "?" && parts[j+1] == ")" {
+				if n := len(out); n >= 2 && out[n-1] == "," && out[n-2] == "(?+)" {
+					out = out[:n-1]
+				} else {
+					out = append(out, "(?+)")
+				}
+				i = j + 1
+				continue
+			}
+		}
+		out = append(out, parts[i])
+	}
+	return strings.Join(out, " ")
+}
+
+// digest 归一化 SQL 的 64 位 FNV 摘要，用于聚合同类慢查询
+func digest(sql string, dqString bool) string {
+	h := fnv.New64a()
+	h.Write([]byte(fingerprint(sql, dqString)))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		dqString bool
+		want     []token
+	}{
+		{"basic", "SELECT * FROM users WHERE id = ?", false, []token{
+			{tokIdent, "SELECT"}, {tokPunct, "*"}, {tokIdent, "FROM"}, {tokIdent, "users"},
+			{tokIdent, "WHERE"}, {tokIdent, "id"}, {tokPunct, "="}, {tokParam, ""},
+		}},
+		{"quoted identifiers", "SELECT `Name`, \"Email\" FROM t", false, []token{
+			{tokIdent, "SELECT"}, {tokQuoted, "name"}, {tokPunct, ","}, {tokQuoted, "email"},
+			{tokIdent, "FROM"}, {tokIdent, "t"},
+		}},
+		{"double quote string", `WHERE name = "a;b"`, true, []token{
+			{tokIdent, "WHERE"}, {tokIdent, "name"}, {tokPunct, "="}, {tokString, ""},
+		}},
+		{"escaped string", `WHERE name = 'it''s' AND x = 'a\'b'`, false, []token{
+			{tokIdent, "WHERE"}, {tokIdent, "name"}, {tokPunct, "="}, {tokString, ""},
+			{tokIdent, "AND"}, {tokIdent, "x"}, {tokPunct, "="}, {tokString, ""},
+		}},
+		{"numbered params", "WHERE a = $1 AND b = @p2", false, []token{
+			{tokIdent, "WHERE"}, {tokIdent, "a"}, {tokPunct, "="}, {tokParam, "1"},
+			{tokIdent, "AND"}, {tokIdent, "b"}, {tokPunct, "="}, {tokParam, "2"},
+		}},
+		{"comments and numbers", "SELECT 1.5, 0x1F -- tail\n/* block */ FROM t", false, []token{
+			{tokIdent, "SELECT"}, {tokNumber, ""}, {tokPunct, ","}, {tokNumber, ""},
+			{tokIdent, "FROM"}, {tokIdent, "t"},
+		}},
+		{"bare dollar", "SELECT $ FROM t", false, []token{
+			{tokIdent, "SELECT"}, {tokPunct, "$"}, {tokIdent, "FROM"}, {tokIdent, "t"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenize(tt.sql, tt.dqString); !slices.Equal(got, tt.want) {
+				t.Fatalf("tokenize(%q)\n got %v\nwant %v", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamColumns(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"equal", "SELECT * FROM users WHERE email = ? AND id = ?", []string{"email", "id"}},
+		{"qualified", "SELECT * FROM users WHERE `users`.`email` = ?", []string{"email"}},
+		{"in list", "WHERE status IN (?, ?) AND name NOT LIKE ?", []string{"status", "status", "name"}},
+		{"between", "WHERE created_at BETWEEN ? AND ? AND id = ?", []string{"created_at", "created_at", "id"}},
+		{"update set", "UPDATE users SET password = ?, name = ? WHERE id = ?", []string{"password", "name", "id"}},
+		{"insert", "INSERT INTO users (name, email) VALUES (?, ?), (?, ?)", []string{"name", "email", "name", "email"}},
+		{"insert quoted", "INSERT INTO `users` (`name`,`token`) VALUES (?,?) RETURNING `id`", []string{"name", "token"}},
+		{"insert nested call", "INSERT INTO users (name, email) VALUES (?, LOWER(?))", []string{"name", "email"}},
+		{"function on value", "WHERE email = LOWER(?)", []string{"email"}},
+		{"function on column", "WHERE LOWER(email) = ?", []string{"email"}},
+		{"function without column", "SELECT COALESCE(?, 'x') FROM t", []string{""}},
+		{"upsert", "INSERT INTO t (a, b) VALUES (?, ?) ON CONFLICT (a) DO UPDATE SET b = ?", []string{"a", "b", "b"}},
+		{"numbered", "WHERE a = $2 AND b = $1", []string{"b", "a"}},
+		{"out of range", "WHERE a = $3", []string{"", ""}},
+		{"limit", "SELECT * FROM t LIMIT ?", []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := paramColumns(tokenize(tt.sql, false), len(tt.want))
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("paramColumns(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamsFilter(t *testing.T) {
+	deny := []string{"*password*", "email"}
+	tests := []struct {
+		name   string
+		cfg    RedactConfig
+		sql    string
+		params []any
+		want   []any
+	}{
+		{"deny", RedactConfig{Enabled: true, Deny: deny},
+			"UPDATE users SET password = ?, name = ? WHERE id = ?", []any{"p", "n", 1}, []any{redactedValue, "n", 1}},
+		{"function argument", RedactConfig{Enabled: true, Deny: deny},
+			"WHERE email = LOWER(?)", []any{"a@b.c"}, []any{redactedValue}},
+		{"unresolved", RedactConfig{Enabled: true, Deny: deny},
+			"SELECT COALESCE(?, 'x')", []any{"secret"}, []any{redactedValue}},
+		{"allow", RedactConfig{Enabled: true, Allow: []string{"id"}},
+			"WHERE id = ? AND name = ?", []any{1, "n"}, []any{1, redactedValue}},
+		{"nil kept", RedactConfig{Enabled: true, Deny: deny},
+			"WHERE email = ?", []any{nil}, []any{nil}},
+		{"pgx exec mode", RedactConfig{Enabled: true, Deny: deny},
+			"WHERE email = $1 AND id = $2", []any{pgx.QueryExecModeExec, "a@b.c", 1}, []any{pgx.QueryExecModeExec, redactedValue, 1}},
+		{"disabled", RedactConfig{Deny: deny},
+			"WHERE email = ?", []any{"a@b.c"}, []any{"a@b.c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SlogAdapter{Redact: tt.cfg}
+			_, got := s.ParamsFilter(context.Background(), tt.sql, tt.params...)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("ParamsFilter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigest(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"literals", "SELECT * FROM t WHERE id = 1", "SELECT * FROM t WHERE id = 42", true},
+		{"strings and params", "WHERE name = 'a'", "WHERE name = ?", true},
+		{"case and whitespace", "select *  from T where ID = ?", "SELECT * FROM t\nWHERE id = ?", true},
+		{"in list length", "WHERE id IN (1, 2, 3)", "WHERE id IN (?)", true},
+		{"multi row values", "INSERT INTO t (a, b) VALUES (1, 2), (3, 4)", "INSERT INTO t (a, b) VALUES (?, ?)", true},
+		{"comments", "SELECT 1 /* hint */", "SELECT 2 -- note", true},
+		{"different column", "WHERE id = ?", "WHERE name = ?", false},
+		{"different table", "SELECT * FROM a", "SELECT * FROM b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := digest(tt.a, false), digest(tt.b, false)
+			if (a == b) != tt.same {
+				t.Fatalf("digest(%q) = %s, digest(%q) = %s, same = %v, want %v",
+					tt.a, a, tt.b, b, a == b, tt.same)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT * FROM `Users` WHERE id IN (1,2,3) AND name = 'x'", "select * from users where id in (?+) and name = ?"},
+		{"INSERT INTO t (a) VALUES (1), (2), (3)", "insert into t ( a ) values (?+)"},
+	}
+	for _, tt := range tests {
+		if got := fingerprint(tt.sql, false); got != tt.want {
+			t.Errorf("fingerprint(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DeletedAt 软删除字段，实体声明该类型字段即开启软删除 (opt-in)
+type DeletedAt = gorm.DeletedAt
+
+const upsertBatchSize = 500
+
+// Page 偏移分页结果
+type Page[T any] struct {
+	Items []*T  `json:"items"`
+	Total int64 `json:"total"`
+	Page  int   `json:"page"`
+	Size  int   `json:"size"`
+}
+
+// CursorPage 游标分页结果，NextCursor 为本页最后一条记录的主键
+type CursorPage[T any] struct {
+	Items      []*T `json:"items"`
+	NextCursor any  `json:"next_cursor,omitempty"`
+	HasMore    bool `json:"has_more"`
+}
+
+// Repository 通用仓储，封装单实体的增删改查，所有方法都通过 GetDB(ctx) 自动感知事务
+type Repository[T any] struct {
+	client *Client
+
+	once   sync.Once
+	schema *schema.Schema
+	err    error
+}
+
+// NewRepository 创建通用仓储
+func NewRepository[T any](client *Client) *Repository[T] {
+	return &Repository[T]{client: client}
+}
+
+// DB 返回绑定了实体 Model 的连接，用于编写仓储未覆盖的查询
+func (r *Repository[T]) DB(ctx context.Context) *gorm.DB {
+	return r.client.GetDB(ctx).Model(new(T))
+}
+
+func (r *Repository[T]) parse() (*schema.Schema, error) {
+	r.once.Do(func() {
+		stmt := &gorm.Statement{DB: r.client.db}
+		if r.err = stmt.Parse(new(T)); r.err == nil {
+			r.schema = stmt.Schema
+		}
+	})
+	return r.schema, r.err
+}
+
+// primaryField 返回实体的主键字段
+func (r *Repository[T]) primaryField() (*schema.Field, error) {
+	s, err := r.parse()
+	if err != nil {
+		return nil, err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return nil, errors.New("db: entity has no primary key")
+	}
+	return s.PrioritizedPrimaryField, nil
+}
+
+// byID 构造主键等值条件
+func (r *Repository[T]) byID(id any) (Spec, error) {
+	pk, err := r.primaryField()
+	if err != nil {
+		return nil, err
+	}
+	return Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName},
+		Value:  id,
+	}), nil
+}
+
+// Create 插入一条记录
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	return r.client.GetDB(ctx).Create(entity).Error
+}
+
+// Update 更新记录。未指定 fields 时只更新非零值字段；指定 fields 时只更新这些列 (允许零值)
+func (r *Repository[T]) Update(ctx context.Context, entity *T, fields ...string) error {
+	tx := r.client.GetDB(ctx).Model(entity)
+	if len(fields) > 0 {
+		tx = tx.Select(fields)
+	}
+	return tx.Updates(entity).Error
+}
+
+// UpdateByID 按主键更新指定列
+func (r *Repository[T]) UpdateByID(ctx context.Context, id any, values map[string]any) error {
+	cond, err := r.byID(id)
+	if err != nil {
+		return err
+	}
+	return cond(r.DB(ctx)).Updates(values).Error
+}
+
+// Delete 按主键删除，实体声明了 DeletedAt 字段时为软删除
+func (r *Repository[T]) Delete(ctx context.Context, id any) error {
+	cond, err := r.byID(id)
+	if err != nil {
+		return err
+	}
+	return cond(r.client.GetDB(ctx)).Delete(new(T)).Error
+}
+
+// ForceDelete 按主键物理删除，忽略软删除
+func (r *Repository[T]) ForceDelete(ctx context.Context, id any) error {
+	cond, err := r.byID(id)
+	if err != nil {
+		return err
+	}
+	return cond(r.client.GetDB(ctx).Unscoped()).Delete(new(T)).Error
+}
+
+// Restore 恢复软删除的记录
+func (r *Repository[T]) Restore(ctx context.Context, id any) error {
+	s, err := r.parse()
+	if err != nil {
+		return err
+	}
+	field := deletedAtField(s)
+	if field == nil {
+		return errors.New("db: entity does not support soft delete")
+	}
+	cond, err := r.byID(id)
+	if err != nil {
+		return err
+	}
+	return cond(r.DB(ctx).Unscoped()).Update(field.DBName, nil).Error
+}
+
+// FindByID 按主键查询，记录不存在时返回 nil, nil
+func (r *Repository[T]) FindByID(ctx context.Context, id any) (*T, error) {
+	cond, err := r.byID(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, cond)
+}
+
+// FindOne 查询满足条件的第一条记录，记录不存在时返回 nil, nil
+func (r *Repository[T]) FindOne(ctx context.Context, specs ...Spec) (*T, error) {
+	var entity T
+	err := applySpecs(r.DB(ctx), specs).Take(&entity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// Find 查询满足条件的全部记录
+func (r *Repository[T]) Find(ctx context.Context, specs ...Spec) ([]*T, error) {
+	var items []*T
+	err := applySpecs(r.DB(ctx), specs).Find(&items).Error
+	return items, err
+}
+
+// List 偏移分页，page 从 1 开始
+func (r *Repository[T]) List(ctx context.Context, page, size int, specs ...Spec) (*Page[T], error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 20
+	}
+	total, err := r.Count(ctx, specs...)
+	if err != nil {
+		return nil, err
+	}
+	result := &Page[T]{Total: total, Page: page, Size: size}
+	if total == 0 {
+		return result, nil
+	}
+	err = applySpecs(r.DB(ctx), specs).Offset((page - 1) * size).Limit(size).Find(&result.Items).Error
+	return result, err
+}
+
+// ListAfter 按主键升序的游标分页，cursor 为上一页的 NextCursor，首页传 nil
+func (r *Repository[T]) ListAfter(ctx context.Context, cursor any, limit int, specs ...Spec) (*CursorPage[T], error) {
+	pk, err := r.primaryField()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	col := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+
+	tx := applySpecs(r.DB(ctx), specs)
+	if cursor != nil {
+		tx = tx.Where(clause.Gt{Column: col, Value: cursor})
+	}
+	var items []*T
+	if err = tx.Order(clause.OrderByColumn{Column: col}).Limit(limit + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	result := &CursorPage[T]{Items: items}
+	if len(items) > limit {
+		result.Items, result.HasMore = items[:limit], true
+	}
+	if n := len(result.Items); n > 0 {
+		last := reflect.ValueOf(result.Items[n-1])
+		result.NextCursor, _ = pk.ValueOf(ctx, last)
+	}
+	return result, nil
+}
+
+// Count 统计满足条件的记录数
+func (r *Repository[T]) Count(ctx context.Context, specs ...Spec) (int64, error) {
+	var total int64
+	err := applySpecs(r.DB(ctx), specs).Count(&total).Error
+	return total, err
+}
+
+// Exists 判断是否存在满足条件的记录
+func (r *Repository[T]) Exists(ctx context.Context, specs ...Spec) (bool, error) {
+	var one int
+	res := applySpecs(r.DB(ctx), specs).Select("1").Limit(1).Scan(&one)
+	return res.RowsAffected > 0, res.Error
+}
+
+// Upsert 批量插入，主键或 conflictColumns 冲突时更新 updateColumns (为空则更新全部列)
+func (r *Repository[T]) Upsert(ctx context.Context, entities []*T, conflictColumns []string, updateColumns ...string) error {
+	if len(entities) == 0 {
+		return nil
+	}
+	onConflict := clause.OnConflict{UpdateAll: len(updateColumns) == 0}
+	for _, c := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: c})
+	}
+	if len(updateColumns) > 0 {
+		onConflict.DoUpdates = clause.AssignmentColumns(updateColumns)
+	}
+	return r.client.GetDB(ctx).Clauses(onConflict).CreateInBatches(entities, upsertBatchSize).Error
+}
+
+// deletedAtField 查找实体的软删除字段
+func deletedAtField(s *schema.Schema) *schema.Field {
+	if s == nil {
+		return nil
+	}
+	deletedAt := reflect.TypeOf(gorm.DeletedAt{})
+	for _, f := range s.Fields {
+		if f.FieldType == deletedAt {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type repoUser struct {
+	ID        int64 `gorm:"primaryKey"`
+	Name      string
+	Email     string `gorm:"uniqueIndex;size:64"`
+	Age       int
+	Note      *string
+	DeletedAt DeletedAt
+}
+
+// plainItem 未开启软删除的实体
+type plainItem struct {
+	ID int64 `gorm:"primaryKey"`
+}
+
+// newRepoClient 创建 SQLite Client 并写入 5 个用户，其中 ID 5 已软删除
+func newRepoClient(t *testing.T) (*Client, *Repository[repoUser]) {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Driver = "sqlite"
+	cfg.DSN = "file:" + filepath.Join(t.TempDir(), "repo.db")
+	cfg.Metrics = false
+	c, err := NewClient(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	ctx := context.Background()
+	if err := c.GetDB(ctx).AutoMigrate(&repoUser{}, &plainItem{}); err != nil {
+		t.Fatal(err)
+	}
+	note := "vip"
+	repo := NewRepository[repoUser](c)
+	for _, u := range []*repoUser{
+		{ID: 1, Name: "alice", Email: "alice@x", Age: 20, Note: &note},
+		{ID: 2, Name: "bob", Email: "bob@x", Age: 30},
+		{ID: 3, Name: "carol", Email: "carol@x", Age: 40},
+		{ID: 4, Name: "dave", Email: "dave@x", Age: 50},
+		{ID: 5, Name: "erin", Email: "erin@x", Age: 60},
+	} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Delete(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	return c, repo
+}
+
+func userIDs(users []*repoUser) []int64 {
+	out := make([]int64, len(users))
+	for i, u := range users {
+		out[i] = u.ID
+	}
+	return out
+}
+
+func TestSpecs(t *testing.T) {
+	_, repo := newRepoClient(t)
+	tests := []struct {
+		name  string
+		specs []Spec
+		want  []int64
+	}{
+		{"none", nil, []int64{1, 2, 3, 4}},
+		{"eq", []Spec{Eq("name", "bob")}, []int64{2}},
+		{"ne", []Spec{Ne("name", "bob")}, []int64{1, 3, 4}},
+		{"range", []Spec{Gt("age", 20), Lte("age", 40)}, []int64{2, 3}},
+		{"gte lt", []Spec{Gte("age", 40), Lt("age", 60)}, []int64{3, 4}},
+		{"between", []Spec{Between("age", 30, 50)}, []int64{2, 3, 4}},
+		{"in", []Spec{In("name", []string{"alice", "dave", "erin"})}, []int64{1, 4}},
+		{"in empty", []Spec{In("name", []string{})}, nil},
+		{"like", []Spec{Like("email", "%o%")}, []int64{2, 3}},
+		{"is null", []Spec{IsNull("note")}, []int64{2, 3, 4}},
+		{"not null", []Spec{NotNull("note")}, []int64{1}},
+		{"or", []Spec{Or(Eq("name", "alice"), Eq("age", 40))}, []int64{1, 3}},
+		{"or grouped with and", []Spec{Gt("age", 20), Or(Eq("name", "alice"), Eq("name", "dave"))}, []int64{4}},
+		{"or of and", []Spec{Or(And(Gt("age", 20), Lt("age", 40)), Eq("name", "dave"))}, []int64{2, 4}},
+		{"or empty", []Spec{Or(), Eq("name", "bob")}, []int64{2}},
+		{"not", []Spec{Not(Eq("name", "bob"))}, []int64{1, 3, 4}},
+		{"not or", []Spec{Not(Or(Eq("name", "bob"), Eq("name", "carol")))}, []int64{1, 4}},
+		{"order desc", []Spec{OrderBy("age", true)}, []int64{4, 3, 2, 1}},
+		{"with trashed", []Spec{WithTrashed()}, []int64{1, 2, 3, 4, 5}},
+		{"only trashed", []Spec{OnlyTrashed()}, []int64{5}},
+		{"only trashed with spec", []Spec{OnlyTrashed(), Eq("name", "bob")}, nil},
+		{"nil spec skipped", []Spec{nil, Eq("name", "bob")}, []int64{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := repo.Find(context.Background(), tt.specs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := userIDs(users)
+			if tt.name != "order desc" {
+				slices.Sort(got)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnlyTrashedWithoutSoftDelete(t *testing.T) {
+	c, _ := newRepoClient(t)
+	ctx := context.Background()
+	repo := NewRepository[plainItem](c)
+	if err := repo.Create(ctx, &plainItem{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	items, err := repo.Find(ctx, OnlyTrashed())
+	if err != nil || len(items) != 0 {
+		t.Fatalf("items = %v, err = %v; want none", items, err)
+	}
+	if err := repo.Restore(ctx, 1); err == nil {
+		t.Fatal("Restore should fail without DeletedAt")
+	}
+}
+
+func TestListAfter(t *testing.T) {
+	_, repo := newRepoClient(t)
+	tests := []struct {
+		name  string
+		limit int
+		specs []Spec
+		want  [][]int64 // 每页的 ID
+	}{
+		{"pages", 3, nil, [][]int64{{1, 2, 3}, {4}}},
+		{"exact boundary", 2, nil, [][]int64{{1, 2}, {3, 4}}},
+		{"single page", 10, nil, [][]int64{{1, 2, 3, 4}}},
+		{"with spec", 1, []Spec{Gt("age", 20)}, [][]int64{{2}, {3}, {4}}},
+		{"with trashed", 3, []Spec{WithTrashed()}, [][]int64{{1, 2, 3}, {4, 5}}},
+		{"empty", 3, []Spec{Eq("name", "nobody")}, [][]int64{{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cursor any
+			for i, want := range tt.want {
+				page, err := repo.ListAfter(context.Background(), cursor, tt.limit, tt.specs...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := userIDs(page.Items); !slices.Equal(got, want) {
+					t.Fatalf("page %d ids = %v, want %v", i, got, want)
+				}
+				if wantMore := i < len(tt.want)-1; page.HasMore != wantMore {
+					t.Fatalf("page %d HasMore = %v, want %v", i, page.HasMore, wantMore)
+				}
+				if len(want) == 0 {
+					if page.NextCursor != nil {
+						t.Fatalf("NextCursor = %v on empty page", page.NextCursor)
+					}
+				} else if page.NextCursor != want[len(want)-1] {
+					t.Fatalf("page %d NextCursor = %v, want %d", i, page.NextCursor, want[len(want)-1])
+				}
+				cursor = page.NextCursor
+			}
+		})
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	tests := []struct {
+		name     string
+		entities []*repoUser
+		conflict []string
+		update   []string
+		want     map[int64]repoUser // 按 ID 检查 Name、Email、Age
+	}{
+		{"empty", nil, nil, nil, map[int64]repoUser{
+			1: {Name: "alice", Email: "alice@x", Age: 20},
+		}},
+		{"primary key update all", []*repoUser{
+			{ID: 1, Name: "alice2", Email: "alice@x", Age: 21},
+			{ID: 6, Name: "frank", Email: "frank@x", Age: 70},
+		}, nil, nil, map[int64]repoUser{
+			1: {Name: "alice2", Email: "alice@x", Age: 21},
+			6: {Name: "frank", Email: "frank@x", Age: 70},
+		}},
+		{"unique column selected fields", []*repoUser{
+			{ID: 10, Name: "bobby", Email: "bob@x", Age: 99},
+		}, []string{"email"}, []string{"name"}, map[int64]repoUser{
+			2: {Name: "bobby", Email: "bob@x", Age: 30},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, repo := newRepoClient(t)
+			ctx := context.Background()
+			if err := repo.Upsert(ctx, tt.entities, tt.conflict, tt.update...); err != nil {
+				t.Fatal(err)
+			}
+			for id, want := range tt.want {
+				got, err := repo.FindByID(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got == nil || got.Name != want.Name || got.Email != want.Email || got.Age != want.Age {
+					t.Fatalf("user %d = %+v, want %+v", id, got, want)
+				}
+			}
+			if n, _ := repo.Count(ctx, WithTrashed(), Eq("id", 10)); n != 0 {
+				t.Fatal("conflicting row was inserted")
+			}
+		})
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	_, repo := newRepoClient(t)
+	ctx := context.Background()
+	exists := func(specs ...Spec) bool {
+		t.Helper()
+		ok, err := repo.Exists(ctx, append(specs, Eq("id", 2))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	steps := []struct {
+		name        string
+		do          func() error
+		visible     bool
+		withTrashed bool
+	}{
+		{"delete", func() error { return repo.Delete(ctx, 2) }, false, true},
+		{"restore", func() error { return repo.Restore(ctx, 2) }, true, true},
+		{"force delete", func() error { return repo.ForceDelete(ctx, 2) }, false, false},
+	}
+	for _, s := range steps {
+		if err := s.do(); err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if got := exists(); got != s.visible {
+			t.Fatalf("%s: visible = %v, want %v", s.name, got, s.visible)
+		}
+		if got := exists(WithTrashed()); got != s.withTrashed {
+			t.Fatalf("%s: with trashed = %v, want %v", s.name, got, s.withTrashed)
+		}
+		if u, err := repo.FindByID(ctx, 2); err != nil || (u != nil) != s.visible {
+			t.Fatalf("%s: FindByID = %v, %v", s.name, u, err)
+		}
+	}
+	if n, err := repo.Count(ctx); err != nil || n != 3 {
+		t.Fatalf("Count = %d, %v; want 3", n, err)
+	}
+}
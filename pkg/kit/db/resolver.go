@@ -0,0 +1,286 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// 从库负载均衡策略
+const (
+	PolicyRandom       = "random"
+	PolicyRoundRobin   = "round_robin"
+	PolicyWeighted     = "weighted"
+	PolicyLeastLatency = "least_latency"
+)
+
+type primaryKey struct{}
+
+type stickyKey struct{}
+
+// WithPrimary 标记 ctx，之后通过 GetDB(ctx) 发起的读请求强制走主库
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// WithReadYourWrites 为 ctx 开启写后读会话 (通常在请求入口调用)。
+// 会话内发生写操作后，在 Config.ReadYourWrites 窗口内的读请求都会走主库，避免读到从库延迟数据。
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(stickyKey{}).(*stickySession); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, stickyKey{}, &stickySession{})
+}
+
+// stickySession 记录会话内最近一次写操作的时间
+type stickySession struct {
+	lastWrite atomic.Int64
+}
+
+func (s *stickySession) within(window time.Duration) bool {
+	last := s.lastWrite.Load()
+	return last != 0 && time.Since(time.Unix(0, last)) < window
+}
+
+// replica 单个从库的运行时信息
+type replica struct {
+	index   int           // 在 Config.Replicas 中的下标
+	pool    gorm.ConnPool // dbresolver 持有的底层连接池
+	weight  int
+	latency atomic.Int64 // 查询耗时的指数滑动平均 (ns)
+	healthy atomic.Bool
+
+	// 以下字段由健康探测维护
+	mu        sync.Mutex
+	lag       time.Duration
+	failures  int
+	lastErr   string
+	lastCheck time.Time
+}
+
+// observe 以 EWMA (alpha=0.2) 更新查询耗时
+func (r *replica) observe(d time.Duration) {
+	for {
+		old := r.latency.Load()
+		next := int64(d)
+		if old != 0 {
+			next = old + (int64(d)-old)/5
+		}
+		if r.latency.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// replicaSet 维护连接池到从库信息的映射，供负载均衡策略使用
+type replicaSet struct {
+	primary  gorm.ConnPool
+	replicas []*replica
+	byPool   map[gorm.ConnPool]*replica
+	ejected  atomic.Int32 // 被摘除的从库数量
+}
+
+func newReplicaSet(cfg Config) (*replicaSet, error) {
+	if len(cfg.ReplicaWeights) > 0 && len(cfg.ReplicaWeights) != len(cfg.Replicas) {
+		return nil, fmt.Errorf("db: replica_weights has %d entries, want %d", len(cfg.ReplicaWeights), len(cfg.Replicas))
+	}
+	set := &replicaSet{byPool: make(map[gorm.ConnPool]*replica, len(cfg.Replicas))}
+	for i := range cfg.Replicas {
+		weight := 1
+		if len(cfg.ReplicaWeights) > 0 {
+			weight = cfg.ReplicaWeights[i]
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("db: replica_weights[%d] must not be negative", i)
+		}
+		r := &replica{index: i, weight: weight}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+	}
+	return set, nil
+}
+
+// bind 在 dbresolver 初始化后绑定连接池，Call 的遍历顺序为 主库 -> 从库 (与配置顺序一致)
+func (s *replicaSet) bind(resolver *dbresolver.DBResolver) error {
+	var pools []gorm.ConnPool
+	if err := resolver.Call(func(pool gorm.ConnPool) error {
+		pools = append(pools, pool)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(pools) != len(s.replicas)+1 {
+		return fmt.Errorf("db: resolver has %d pools, want %d", len(pools), len(s.replicas)+1)
+	}
+	s.primary = pools[0]
+	for i, r := range s.replicas {
+		r.pool = pools[i+1]
+		s.byPool[r.pool] = r
+	}
+	return nil
+}
+
+func (s *replicaSet) lookup(pool gorm.ConnPool) (*replica, bool) {
+	if p, ok := pool.(*gorm.PreparedStmtDB); ok {
+		pool = p.ConnPool
+	}
+	r, ok := s.byPool[pool]
+	return r, ok
+}
+
+// eject 摘除从库，状态发生变化时返回 true
+func (s *replicaSet) eject(r *replica) bool {
+	if r.healthy.CompareAndSwap(true, false) {
+		s.ejected.Add(1)
+		return true
+	}
+	return false
+}
+
+// restore 恢复从库，状态发生变化时返回 true
+func (s *replicaSet) restore(r *replica) bool {
+	if r.healthy.CompareAndSwap(false, true) {
+		s.ejected.Add(-1)
+		return true
+	}
+	return false
+}
+
+// allEjected 所有从库均被摘除时，读请求需要回退到主库
+func (s *replicaSet) allEjected() bool {
+	return int(s.ejected.Load()) == len(s.replicas)
+}
+
+// policy 根据配置创建 dbresolver 负载均衡策略，并在其外层过滤被摘除的从库
+func (s *replicaSet) policy(name string) (dbresolver.Policy, error) {
+	var inner dbresolver.Policy
+	switch name {
+	case "", PolicyRandom:
+		inner = dbresolver.RandomPolicy{}
+	case PolicyRoundRobin:
+		inner = dbresolver.StrictRoundRobinPolicy()
+	case PolicyWeighted:
+		inner = dbresolver.PolicyFunc(s.resolveWeighted)
+	case PolicyLeastLatency:
+		inner = dbresolver.PolicyFunc(s.resolveLeastLatency)
+	default:
+		return nil, fmt.Errorf("db: unsupported replica policy: %s", name)
+	}
+	return dbresolver.PolicyFunc(func(pools []gorm.ConnPool) gorm.ConnPool {
+		if s.ejected.Load() == 0 {
+			return inner.Resolve(pools)
+		}
+		healthy := make([]gorm.ConnPool, 0, len(pools))
+		for _, p := range pools {
+			if r, ok := s.lookup(p); !ok || r.healthy.Load() {
+				healthy = append(healthy, p)
+			}
+		}
+		switch len(healthy) {
+		case 0:
+			return s.primary
+		case 1:
+			return healthy[0]
+		default:
+			return inner.Resolve(healthy)
+		}
+	}), nil
+}
+
+func (s *replicaSet) resolveWeighted(pools []gorm.ConnPool) gorm.ConnPool {
+	total := 0
+	for _, p := range pools {
+		if r, ok := s.lookup(p); ok {
+			total += r.weight
+		}
+	}
+	if total == 0 {
+		return pools[rand.IntN(len(pools))]
+	}
+	n := rand.IntN(total)
+	for _, p := range pools {
+		if r, ok := s.lookup(p); ok {
+			if n -= r.weight; n < 0 {
+				return p
+			}
+		}
+	}
+	return pools[len(pools)-1]
+}
+
+// resolveLeastLatency 选择平均耗时最低的从库，尚无样本的从库优先以便尽快采样
+func (s *replicaSet) resolveLeastLatency(pools []gorm.ConnPool) gorm.ConnPool {
+	best, bestLat := pools[0], int64(-1)
+	for _, p := range pools {
+		r, ok := s.lookup(p)
+		if !ok {
+			continue
+		}
+		if lat := r.latency.Load(); bestLat < 0 || lat < bestLat {
+			best, bestLat = p, lat
+		}
+	}
+	return best
+}
+
+const latencyStartKey = "kit:replica_latency_start"
+
+// registerRoutingCallbacks 注册从库耗时采样与写后读标记回调
+func (c *Client) registerRoutingCallbacks(db *gorm.DB) error {
+	start := func(tx *gorm.DB) {
+		tx.InstanceSet(latencyStartKey, time.Now())
+	}
+	end := func(tx *gorm.DB) {
+		begin, ok := tx.InstanceGet(latencyStartKey)
+		if !ok {
+			return
+		}
+		if r, ok := c.replicas.lookup(tx.Statement.ConnPool); ok {
+			r.observe(time.Since(begin.(time.Time)))
+		}
+	}
+	markWrite := func(tx *gorm.DB) {
+		if s, ok := tx.Statement.Context.Value(stickyKey{}).(*stickySession); ok {
+			s.lastWrite.Store(time.Now().UnixNano())
+		}
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Query().Before("gorm:query").Register("kit:replica_latency_start", start),
+		cb.Query().After("gorm:query").Register("kit:replica_latency_end", end),
+		cb.Row().Before("gorm:row").Register("kit:replica_latency_start", start),
+		cb.Row().After("gorm:row").Register("kit:replica_latency_end", end),
+		cb.Create().After("gorm:create").Register("kit:mark_write", markWrite),
+		cb.Update().After("gorm:update").Register("kit:mark_write", markWrite),
+		cb.Delete().After("gorm:delete").Register("kit:mark_write", markWrite),
+		cb.Raw().After("gorm:raw").Register("kit:mark_write", markWrite),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usePrimary 判断当前 ctx 的读请求是否需要走主库
+func (c *Client) usePrimary(ctx context.Context) bool {
+	if force, _ := ctx.Value(primaryKey{}).(bool); force {
+		return true
+	}
+	// dbresolver 只有一个从库时不经过 Policy，需在这里回退
+	if c.replicas.allEjected() {
+		return true
+	}
+	if c.stickyWindow <= 0 {
+		return false
+	}
+	s, ok := ctx.Value(stickyKey{}).(*stickySession)
+	return ok && s.within(c.stickyWindow)
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryPolicy 事务重试策略
+type RetryPolicy struct {
+	MaxAttempts int           // 最大尝试次数 (含首次)
+	BaseDelay   time.Duration // 首次重试的退避基数，之后按指数增长
+	MaxDelay    time.Duration // 单次退避上限
+	// Retryable 判断错误是否可重试，为空时使用 IsRetryable
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy 默认重试 3 次，退避 20ms 起步，上限 1s
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   20 * time.Millisecond,
+		MaxDelay:    time.Second,
+		Retryable:   IsRetryable,
+	}
+}
+
+// WithRetry 事务因死锁等可重试错误失败时，按策略重新执行整个闭包。
+// 仅在 WithTx 真正开启新事务时生效，加入外层事务或 SavePoint 时不会重试。
+func WithRetry(p RetryPolicy) TxOption {
+	return func(o *txOptions) { o.retry = &p }
+}
+
+// IsRetryable 默认的可重试错误判断
+//   - MySQL: 1213 死锁, 1205 锁等待超时
+//   - PostgreSQL: 40001 序列化失败, 40P01 死锁
+func IsRetryable(err error) bool {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == 1213 || myErr.Number == 1205
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return false
+}
+
+// backoff 计算第 attempt 次失败后的等待时间 (Full Jitter)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay << (attempt - 1)
+	if p.MaxDelay > 0 && (d <= 0 || d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		// 未设置上限且位移溢出
+		return p.BaseDelay
+	}
+	return rand.N(d) + 1
+}
+
+// withRetry 按策略执行 run，直到成功、遇到不可重试错误或次数耗尽
+func (c *Client) withRetry(ctx context.Context, p RetryPolicy, run func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		delay := p.backoff(attempt)
+		c.logger.txRetry(ctx, attempt, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Spec 查询规约，本质是一个 Gorm Scope，可直接用于 db.Scopes(...)
+// 列名统一通过 clause.Column 引用，会被正确转义，避免拼接 SQL
+type Spec func(tx *gorm.DB) *gorm.DB
+
+// Where 将任意 clause 表达式包装为 Spec
+func Where(exprs ...clause.Expression) Spec {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Clauses(clause.Where{Exprs: exprs})
+	}
+}
+
+// Eq column = value
+func Eq[V any](column string, value V) Spec {
+	return Where(clause.Eq{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Ne column <> value
+func Ne[V any](column string, value V) Spec {
+	return Where(clause.Neq{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Gt column > value
+func Gt[V any](column string, value V) Spec {
+	return Where(clause.Gt{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Gte column >= value
+func Gte[V any](column string, value V) Spec {
+	return Where(clause.Gte{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Lt column < value
+func Lt[V any](column string, value V) Spec {
+	return Where(clause.Lt{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Lte column <= value
+func Lte[V any](column string, value V) Spec {
+	return Where(clause.Lte{Column: clause.Column{Name: column}, Value: value})
+}
+
+// In column IN (values...)，values 为空时不匹配任何记录
+func In[V any](column string, values []V) Spec {
+	if len(values) == 0 {
+		return Where(clause.Expr{SQL: "1 = 0"})
+	}
+	vs := make([]any, len(values))
+	for i, v := range values {
+		vs[i] = v
+	}
+	return Where(clause.IN{Column: clause.Column{Name: column}, Values: vs})
+}
+
+// Like column LIKE pattern
+func Like(column, pattern string) Spec {
+	return Where(clause.Like{Column: clause.Column{Name: column}, Value: pattern})
+}
+
+// IsNull column IS NULL
+func IsNull(column string) Spec {
+	return Where(clause.Expr{SQL: "? IS NULL", Vars: []any{clause.Column{Name: column}}})
+}
+
+// NotNull column IS NOT NULL
+func NotNull(column string) Spec {
+	return Where(clause.Expr{SQL: "? IS NOT NULL", Vars: []any{clause.Column{Name: column}}})
+}
+
+// Between column BETWEEN lo AND hi
+func Between[V any](column string, lo, hi V) Spec {
+	return Where(clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []any{clause.Column{Name: column}, lo, hi}})
+}
+
+// And 组合多个 Spec，全部满足
+func And(specs ...Spec) Spec {
+	return func(tx *gorm.DB) *gorm.DB {
+		for _, s := range specs {
+			tx = s(tx)
+		}
+		return tx
+	}
+}
+
+// Or 组合多个 Spec，满足其一即可，生成带括号的分组条件
+func Or(specs ...Spec) Spec {
+	return func(tx *gorm.DB) *gorm.DB {
+		if len(specs) == 0 {
+			return tx
+		}
+		group := tx.Session(&gorm.Session{NewDB: true})
+		for i, s := range specs {
+			cond := s(tx.Session(&gorm.Session{NewDB: true}))
+			if i == 0 {
+				group = group.Where(cond)
+			} else {
+				group = group.Or(cond)
+			}
+		}
+		return tx.Where(group)
+	}
+}
+
+// Not 对 Spec 取反
+func Not(spec Spec) Spec {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Not(spec(tx.Session(&gorm.Session{NewDB: true})))
+	}
+}
+
+// OrderBy 排序
+func OrderBy(column string, desc bool) Spec {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+}
+
+// WithTrashed 查询结果包含已软删除的记录
+func WithTrashed() Spec {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}
+}
+
+// OnlyTrashed 只查询已软删除的记录，实体未启用软删除时不匹配任何记录
+func OnlyTrashed() Spec {
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Unscoped()
+		if err := tx.Statement.Parse(tx.Statement.Model); err != nil {
+			_ = tx.AddError(err)
+			return tx
+		}
+		field := deletedAtField(tx.Statement.Schema)
+		if field == nil {
+			return tx.Where(clause.Expr{SQL: "1 = 0"})
+		}
+		return tx.Where(clause.Expr{SQL: "? IS NOT NULL", Vars: []any{clause.Column{Table: clause.CurrentTable, Name: field.DBName}}})
+	}
+}
+
+func applySpecs(tx *gorm.DB, specs []Spec) *gorm.DB {
+	for _, s := range specs {
+		if s != nil {
+			tx = s(tx)
+		}
+	}
+	return tx
+}
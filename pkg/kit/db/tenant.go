@@ -0,0 +1,516 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// 多租户模式
+const (
+	TenantRow    = "row"    // 共享表，按租户列自动过滤与填充
+	TenantSchema = "schema" // 每个租户独立 schema/库，按租户切换连接池
+)
+
+var (
+	// ErrNoTenant 多租户模式下 ctx 未携带租户，且未通过 WithAllTenants 声明跨租户访问
+	ErrNoTenant = errors.New("db: tenant is required")
+	// ErrTenantMismatch 写入记录的租户与 ctx 中的租户不一致
+	ErrTenantMismatch = errors.New("db: tenant mismatch")
+	// ErrTenantUpsert 当前方言无法为冲突更新追加租户条件 (如 MySQL 的 ON DUPLICATE KEY UPDATE)，
+	// 拒绝带 DO UPDATE 的 Upsert 与 Save 回退插入，避免覆盖其他租户的记录
+	ErrTenantUpsert = errors.New("db: tenant-scoped upsert is not supported by this dialect")
+	// ErrUnknownTenant schema 模式下租户不在 tenant.allow 中，或被 TenantConfig.Resolve 拒绝
+	ErrUnknownTenant = errors.New("db: unknown tenant")
+)
+
+// defaultMaxTenantPools schema 模式默认同时保持的租户连接池个数
+const defaultMaxTenantPools = 100
+
+// tenantEvictGrace 淘汰的租户连接池延迟关闭，等待已取得连接的请求与事务结束
+const tenantEvictGrace = time.Minute
+
+// TenantResolver 校验 schema 模式的租户是否存在 (如查询租户注册表)，租户连接池未建立时调用
+type TenantResolver func(ctx context.Context, tenant string) (bool, error)
+
+// TenantConfig 多租户配置，Mode 为空时关闭
+type TenantConfig struct {
+	Mode         string `mapstructure:"mode"`           // row | schema
+	Column       string `mapstructure:"column"`         // row 模式的租户列，默认 tenant_id；不含该列的表不受影响
+	DSN          string `mapstructure:"dsn"`            // schema 模式的连接串模板，{tenant} 替换为租户 ID
+	MaxOpenConns int    `mapstructure:"max_open_conns"` // schema 模式每个租户连接池的最大连接数，默认同 max_open_conns
+	MaxPools     int    `mapstructure:"max_pools"`      // schema 模式同时保持的租户连接池个数，超出时关闭最久未使用的，默认 100
+	AllowMissing bool   `mapstructure:"allow_missing"`  // ctx 未携带租户时不过滤 (row) 或使用默认库 (schema)，仅用于迁移过渡期
+
+	// schema 模式只为 Allow 中的租户或 Resolve 返回 true 的租户建立连接池，二者至少配置一个
+	Allow   []string       `mapstructure:"allow"`
+	Resolve TenantResolver `mapstructure:"-"`
+}
+
+type tenantKey struct{}
+
+type allTenantsKey struct{}
+
+// WithTenant 为 ctx 绑定租户 (通常在请求入口根据认证信息调用)，之后的查询只能访问该租户的数据
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 返回 ctx 绑定的租户
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// WithAllTenants 显式声明跨租户访问 (后台管理、统计任务)：row 模式不再追加租户条件，schema 模式使用默认库。
+// 与 WithTenant 同时存在时以 WithTenant 为准
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+func allTenants(ctx context.Context) bool {
+	all, _ := ctx.Value(allTenantsKey{}).(bool)
+	return all
+}
+
+// registerTenantCallbacks row 模式：含租户列的模型在查询/更新/删除时追加租户条件，创建时填充租户列。
+// Raw / Exec 与未指定 Model 的 Table 查询无法识别模型，不会被处理
+func registerTenantCallbacks(db *gorm.DB, cfg TenantConfig) error {
+	column := cfg.Column
+	if column == "" {
+		column = "tenant_id"
+	}
+	t := &tenantScope{column: column, allowMissing: cfg.AllowMissing}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("kit:tenant_fill", t.fill),
+		cb.Query().Before("gorm:query").Register("kit:tenant_scope", t.scope),
+		cb.Row().Before("gorm:row").Register("kit:tenant_scope", t.scope),
+		cb.Update().Before("gorm:update").Register("kit:tenant_scope", t.scope),
+		cb.Delete().Before("gorm:delete").Register("kit:tenant_scope", t.scope),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type tenantScope struct {
+	column       string
+	allowMissing bool
+}
+
+// resolve 返回本次语句需要使用的租户，skip 为 true 表示无需处理
+func (t *tenantScope) resolve(tx *gorm.DB) (field *schema.Field, tenant any, skip bool) {
+	if tx.Error != nil || tx.Statement.Schema == nil {
+		return nil, nil, true
+	}
+	field = tx.Statement.Schema.FieldsByDBName[t.column]
+	if field == nil {
+		return nil, nil, true
+	}
+	ctx := tx.Statement.Context
+	id, ok := TenantFromContext(ctx)
+	if !ok {
+		if !allTenants(ctx) && !t.allowMissing {
+			_ = tx.AddError(fmt.Errorf("%w: %s", ErrNoTenant, tx.Statement.Table))
+		}
+		return nil, nil, true
+	}
+	tenant, err := tenantValue(field, id)
+	if err != nil {
+		_ = tx.AddError(err)
+		return nil, nil, true
+	}
+	return field, tenant, false
+}
+
+func (t *tenantScope) scope(tx *gorm.DB) {
+	field, tenant, skip := t.resolve(tx)
+	if skip {
+		return
+	}
+	tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenant},
+	}})
+}
+
+// fill 填充未设置的租户列，已设置且与 ctx 不一致时拒绝写入
+func (t *tenantScope) fill(tx *gorm.DB) {
+	field, tenant, skip := t.resolve(tx)
+	if skip {
+		return
+	}
+	if t.guardConflict(tx, field); tx.Error != nil {
+		return
+	}
+	ctx := tx.Statement.Context
+	set := func(rv reflect.Value) {
+		current, zero := field.ValueOf(ctx, rv)
+		if zero {
+			if err := field.Set(ctx, rv, tenant); err != nil {
+				_ = tx.AddError(err)
+			}
+			return
+		}
+		if fmt.Sprint(current) != fmt.Sprint(tenant) {
+			_ = tx.AddError(fmt.Errorf("%w: %v != %v", ErrTenantMismatch, current, tenant))
+		}
+	}
+
+	rv := tx.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	case reflect.Map:
+		// Create(map) 不经过字段解析，直接写入租户列
+		if m, ok := tx.Statement.Dest.(map[string]any); ok {
+			if _, exists := m[field.DBName]; !exists {
+				m[field.DBName] = tenant
+			}
+		}
+	}
+}
+
+// guardConflict Upsert (以及 Save 更新 0 行后回退的插入) 冲突时可能命中其他租户的记录：
+// 冲突更新不修改租户列，并追加 "目标行租户 = 插入行租户" 条件，命中其他租户时影响 0 行。
+// 只有 PostgreSQL 与 SQLite 支持冲突更新的 WHERE，其他方言直接拒绝
+func (t *tenantScope) guardConflict(tx *gorm.DB, field *schema.Field) {
+	c, ok := tx.Statement.Clauses["ON CONFLICT"]
+	if !ok {
+		return
+	}
+	onConflict, ok := c.Expression.(clause.OnConflict)
+	if !ok || onConflict.DoNothing {
+		return
+	}
+	switch tx.Dialector.Name() {
+	case "postgres", "sqlite":
+	default:
+		_ = tx.AddError(fmt.Errorf("%w: %s", ErrTenantUpsert, tx.Dialector.Name()))
+		return
+	}
+
+	if onConflict.UpdateAll {
+		// Gorm 在 gorm:create 中才展开 UpdateAll，此处提前展开以便排除租户列
+		onConflict.UpdateAll = false
+		onConflict.DoUpdates = updateAllColumns(tx.Statement)
+		if len(onConflict.Columns) == 0 {
+			for _, pf := range tx.Statement.Schema.PrimaryFields {
+				onConflict.Columns = append(onConflict.Columns, clause.Column{Name: pf.DBName})
+			}
+		}
+	}
+	updates := make(clause.Set, 0, len(onConflict.DoUpdates))
+	for _, a := range onConflict.DoUpdates {
+		if a.Column.Name != field.DBName {
+			updates = append(updates, a)
+		}
+	}
+	onConflict.DoUpdates = updates
+	if len(updates) == 0 {
+		onConflict.DoNothing = true
+	}
+	onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Eq{
+		Column: clause.Column{Table: tx.Statement.Table, Name: field.DBName},
+		Value:  clause.Column{Table: "excluded", Name: field.DBName},
+	})
+	tx.Statement.AddClause(onConflict)
+}
+
+// updateAllColumns 与 Gorm 展开 UpdateAll 的规则一致：非主键、无数据库默认值、非自动创建时间的可写列
+func updateAllColumns(stmt *gorm.Statement) clause.Set {
+	selects, restricted := stmt.SelectAndOmitColumns(true, true)
+	var columns []string
+	for _, dbName := range stmt.Schema.DBNames {
+		f := stmt.Schema.FieldsByDBName[dbName]
+		if f == nil || !f.Creatable || f.PrimaryKey || f.AutoCreateTime > 0 {
+			continue
+		}
+		if v, ok := selects[dbName]; (ok && !v) || (!ok && restricted) {
+			continue
+		}
+		if f.HasDefaultValue && f.DefaultValueInterface == nil && !strings.EqualFold(f.DefaultValue, "NULL") {
+			continue
+		}
+		columns = append(columns, dbName)
+	}
+	return clause.AssignmentColumns(columns)
+}
+
+// tenantValue 将租户 ID 转换为租户列的类型，避免 PostgreSQL 等驱动拒绝字符串参数
+func tenantValue(f *schema.Field, id string) (any, error) {
+	switch f.DataType {
+	case schema.Int:
+		v, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("db: invalid tenant %q: %w", id, err)
+		}
+		return v, nil
+	case schema.Uint:
+		v, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("db: invalid tenant %q: %w", id, err)
+		}
+		return v, nil
+	default:
+		return id, nil
+	}
+}
+
+// tenantIDRe schema 模式下租户 ID 会拼入连接串，只允许安全字符
+var tenantIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,63}$`)
+
+// tenantPools schema 模式：每个租户按 DSN 模板懒加载一个独立的 Client，按 LRU 保留至多 max 个
+type tenantPools struct {
+	cfg          Config // 租户 Client 使用的配置 (DSN 为模板)
+	l            *slog.Logger
+	name         string
+	metrics      bool
+	allowMissing bool
+	allow        map[string]bool
+	resolve      TenantResolver
+	max          int
+	open         func(tenant string) (*Client, error) // 建立租户连接池，默认 openClient
+
+	mu      sync.Mutex
+	clients map[string]*list.Element // 值为 *tenantPool
+	lru     *list.List               // 队首为最近使用
+	evicted map[*Client]*time.Timer  // 已淘汰、等待关闭的连接池
+	dialing map[string]*tenantDial   // 正在建立连接池的租户，同一租户只建立一次
+	closed  bool
+}
+
+type tenantPool struct {
+	tenant string
+	client *Client
+}
+
+// tenantDial 一次进行中的连接池建立，done 关闭后 client/err 可读
+type tenantDial struct {
+	done   chan struct{}
+	client *Client
+	err    error
+}
+
+func newTenantPools(name string, cfg Config, l *slog.Logger) (*tenantPools, error) {
+	if !strings.Contains(cfg.Tenant.DSN, "{tenant}") {
+		return nil, errors.New("db: tenant.dsn must contain {tenant}")
+	}
+	if len(cfg.Tenant.Allow) == 0 && cfg.Tenant.Resolve == nil {
+		return nil, errors.New("db: schema tenant mode requires tenant.allow or TenantConfig.Resolve")
+	}
+	child := cfg
+	child.DSN = cfg.Tenant.DSN
+	child.Replicas = nil
+	child.HealthCheck.Interval = 0
+	child.Explain.Enabled = false
+	// 指标按所属 Client 汇总，不以租户作为标签
+	child.Metrics = false
+	if cfg.Tenant.MaxOpenConns > 0 {
+		child.MaxOpenConns = cfg.Tenant.MaxOpenConns
+		child.MaxIdleConns = min(child.MaxIdleConns, child.MaxOpenConns)
+	}
+	child.Tenant = TenantConfig{}
+	p := &tenantPools{
+		cfg:          child,
+		l:            l,
+		name:         name,
+		metrics:      cfg.Metrics,
+		allowMissing: cfg.Tenant.AllowMissing,
+		allow:        make(map[string]bool, len(cfg.Tenant.Allow)),
+		resolve:      cfg.Tenant.Resolve,
+		max:          cfg.Tenant.MaxPools,
+		clients:      map[string]*list.Element{},
+		lru:          list.New(),
+		evicted:      map[*Client]*time.Timer{},
+		dialing:      map[string]*tenantDial{},
+	}
+	if p.max <= 0 {
+		p.max = defaultMaxTenantPools
+	}
+	p.open = p.openClient
+	for _, tenant := range cfg.Tenant.Allow {
+		p.allow[tenant] = true
+	}
+	return p, nil
+}
+
+// get 返回租户对应的 Client，首次访问时校验租户并建立连接池
+func (p *tenantPools) get(ctx context.Context, tenant string) (*Client, error) {
+	if !tenantIDRe.MatchString(tenant) {
+		return nil, fmt.Errorf("db: invalid tenant %q", tenant)
+	}
+	p.mu.Lock()
+	if e, ok := p.clients[tenant]; ok {
+		p.lru.MoveToFront(e)
+		p.mu.Unlock()
+		return e.Value.(*tenantPool).client, nil
+	}
+	p.mu.Unlock()
+
+	if err := p.check(ctx, tenant); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	// 校验期间其他请求可能已建立连接池
+	if e, ok := p.clients[tenant]; ok {
+		p.lru.MoveToFront(e)
+		p.mu.Unlock()
+		return e.Value.(*tenantPool).client, nil
+	}
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrNotReady
+	}
+	// 建立连接 (MySQL 还会查询版本) 可能很慢，在锁外进行，不阻塞其他租户
+	d, ok := p.dialing[tenant]
+	if !ok {
+		d = &tenantDial{done: make(chan struct{})}
+		p.dialing[tenant] = d
+		go p.dial(tenant, d)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-d.done:
+		return d.client, d.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// dial 建立租户连接池后加入 LRU；期间若连接池已关闭或已有同租户的连接池，则关闭新建的
+func (p *tenantPools) dial(tenant string, d *tenantDial) {
+	defer close(d.done)
+	c, err := p.open(tenant)
+
+	p.mu.Lock()
+	delete(p.dialing, tenant)
+	if err != nil {
+		p.mu.Unlock()
+		d.err = err
+		return
+	}
+	var loser *Client
+	switch e, ok := p.clients[tenant]; {
+	case p.closed:
+		loser, d.err = c, ErrNotReady
+	case ok:
+		loser, d.client = c, e.Value.(*tenantPool).client
+		p.lru.MoveToFront(e)
+	default:
+		d.client = c
+		p.clients[tenant] = p.lru.PushFront(&tenantPool{tenant: tenant, client: c})
+		for p.lru.Len() > p.max {
+			p.evict(p.lru.Back())
+		}
+	}
+	p.mu.Unlock()
+	if loser != nil {
+		_ = loser.Close()
+	}
+}
+
+func (p *tenantPools) openClient(tenant string) (*Client, error) {
+	cfg := p.cfg
+	cfg.DSN = strings.ReplaceAll(cfg.DSN, "{tenant}", tenant)
+	c, err := NewNamedClient(p.name+"@"+tenant, cfg, p.l)
+	if err != nil {
+		return nil, fmt.Errorf("db: open tenant %q: %w", tenant, err)
+	}
+	if p.metrics {
+		if err := c.db.Use(newMetricsPlugin(p.name)); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+	c.ready.Store(true)
+	return c, nil
+}
+
+// check 租户必须在 allow 中或通过 Resolve 校验，避免按任意请求值建立连接池
+func (p *tenantPools) check(ctx context.Context, tenant string) error {
+	if p.allow[tenant] {
+		return nil
+	}
+	if p.resolve != nil {
+		ok, err := p.resolve(ctx, tenant)
+		if err != nil {
+			return fmt.Errorf("db: resolve tenant %q: %w", tenant, err)
+		}
+		if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownTenant, tenant)
+}
+
+// evict 移出最久未使用的连接池，tenantEvictGrace 后关闭；调用方需持有 p.mu
+func (p *tenantPools) evict(e *list.Element) {
+	tp := p.lru.Remove(e).(*tenantPool)
+	delete(p.clients, tp.tenant)
+	p.l.Info("tenant_pool_evicted", slog.String("db", p.name), slog.String("tenant", tp.tenant))
+	c := tp.client
+	p.evicted[c] = time.AfterFunc(tenantEvictGrace, func() {
+		p.mu.Lock()
+		delete(p.evicted, c)
+		p.mu.Unlock()
+		if err := c.Close(); err != nil {
+			p.l.Warn("tenant_pool_close_failed", slog.String("db", p.name), slog.String("tenant", tp.tenant), slog.Any("err", err))
+		}
+	})
+}
+
+func (p *tenantPools) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	var errs []error
+	for tenant, e := range p.clients {
+		errs = append(errs, e.Value.(*tenantPool).client.Close())
+		delete(p.clients, tenant)
+	}
+	p.lru.Init()
+	for c, timer := range p.evicted {
+		if timer.Stop() {
+			errs = append(errs, c.Close())
+		}
+		delete(p.evicted, c)
+	}
+	return errors.Join(errs...)
+}
+
+// root 返回 ctx 对应的根连接：schema 模式下按租户切换到租户连接池
+func (c *Client) root(ctx context.Context) (*Client, error) {
+	if c.tenants == nil {
+		return c, nil
+	}
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		if allTenants(ctx) || c.tenants.allowMissing {
+			return c, nil
+		}
+		return nil, ErrNoTenant
+	}
+	return c.tenants.get(ctx, tenant)
+}
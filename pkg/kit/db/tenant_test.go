@@ -0,0 +1,386 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tenantDoc struct {
+	ID       int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name     string
+	TenantID string
+}
+
+// newTenantClient 创建 row 模式的 SQLite Client，并以 t1、t2 各写入一条记录
+func newTenantClient(t *testing.T) *Client {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Driver = "sqlite"
+	cfg.DSN = "file:" + filepath.Join(t.TempDir(), "tenant.db")
+	cfg.Metrics = false
+	cfg.MaxOpenConns = 1
+	cfg.Tenant = TenantConfig{Mode: TenantRow}
+	c, err := NewClient(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	if err := c.GetDB(WithAllTenants(context.Background())).AutoMigrate(&tenantDoc{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []tenantDoc{{ID: 1, Name: "a", TenantID: "t1"}, {ID: 2, Name: "b", TenantID: "t2"}} {
+		if err := c.GetDB(WithTenant(context.Background(), d.TenantID)).Create(&d).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// allDocs 跨租户读取全部记录，按 ID 索引
+func allDocs(t *testing.T, c *Client) map[int64]tenantDoc {
+	t.Helper()
+	var docs []tenantDoc
+	if err := c.GetDB(WithAllTenants(context.Background())).Find(&docs).Error; err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[int64]tenantDoc, len(docs))
+	for _, d := range docs {
+		out[d.ID] = d
+	}
+	return out
+}
+
+func TestTenantScope(t *testing.T) {
+	c := newTenantClient(t)
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		want    int
+		wantErr error
+	}{
+		{"tenant", WithTenant(context.Background(), "t1"), 1, nil},
+		{"other tenant", WithTenant(context.Background(), "t3"), 0, nil},
+		{"all tenants", WithAllTenants(context.Background()), 2, nil},
+		{"tenant wins over all", WithTenant(WithAllTenants(context.Background()), "t2"), 1, nil},
+		{"missing", context.Background(), 0, ErrNoTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var docs []tenantDoc
+			err := c.GetDB(tt.ctx).Find(&docs).Error
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(docs) != tt.want {
+				t.Fatalf("got %d rows, want %d", len(docs), tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantScopeWrite(t *testing.T) {
+	c := newTenantClient(t)
+	ctx := WithTenant(context.Background(), "t2")
+	tests := []struct {
+		name string
+		run  func(db *gorm.DB) *gorm.DB
+	}{
+		{"update", func(db *gorm.DB) *gorm.DB {
+			return db.Model(&tenantDoc{}).Where("id = ?", 1).Update("name", "x")
+		}},
+		{"delete", func(db *gorm.DB) *gorm.DB { return db.Delete(&tenantDoc{}, 1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.run(c.GetDB(ctx))
+			if res.Error != nil || res.RowsAffected != 0 {
+				t.Fatalf("rows = %d, err = %v, want 0 rows", res.RowsAffected, res.Error)
+			}
+			if d := allDocs(t, c)[1]; d.Name != "a" {
+				t.Fatalf("t1 row changed: %+v", d)
+			}
+		})
+	}
+}
+
+func TestTenantFill(t *testing.T) {
+	c := newTenantClient(t)
+	tests := []struct {
+		name       string
+		doc        tenantDoc
+		wantTenant string
+		wantErr    error
+	}{
+		{"fill", tenantDoc{ID: 10}, "t1", nil},
+		{"same tenant", tenantDoc{ID: 11, TenantID: "t1"}, "t1", nil},
+		{"mismatch", tenantDoc{ID: 12, TenantID: "t2"}, "", ErrTenantMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := tt.doc
+			err := c.GetDB(WithTenant(context.Background(), "t1")).Create(&doc).Error
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := allDocs(t, c)[doc.ID].TenantID; got != tt.wantTenant {
+				t.Fatalf("tenant = %q, want %q", got, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func TestTenantUpsert(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      func(ctx context.Context, c *Client) *gorm.DB
+		wantRows int64
+	}{
+		{"save other tenant", func(ctx context.Context, c *Client) *gorm.DB {
+			return c.GetDB(ctx).Save(&tenantDoc{ID: 1, Name: "x"})
+		}, 0},
+		{"upsert all other tenant", func(ctx context.Context, c *Client) *gorm.DB {
+			return c.GetDB(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&tenantDoc{ID: 1, Name: "x"})
+		}, 0},
+		{"upsert columns other tenant", func(ctx context.Context, c *Client) *gorm.DB {
+			return c.GetDB(ctx).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "tenant_id"}),
+			}).Create(&tenantDoc{ID: 1, Name: "x"})
+		}, 0},
+		{"save own tenant", func(ctx context.Context, c *Client) *gorm.DB {
+			return c.GetDB(ctx).Save(&tenantDoc{ID: 2, Name: "x"})
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTenantClient(t)
+			res := tt.run(WithTenant(context.Background(), "t2"), c)
+			if res.Error != nil || res.RowsAffected != tt.wantRows {
+				t.Fatalf("rows = %d, err = %v, want %d rows", res.RowsAffected, res.Error, tt.wantRows)
+			}
+			if d := allDocs(t, c)[1]; d.Name != "a" || d.TenantID != "t1" {
+				t.Fatalf("t1 row changed: %+v", d)
+			}
+		})
+	}
+}
+
+func TestRepositoryUpsertTenant(t *testing.T) {
+	c := newTenantClient(t)
+	repo := NewRepository[tenantDoc](c)
+	ctx := WithTenant(context.Background(), "t2")
+	if err := repo.Upsert(ctx, []*tenantDoc{{ID: 1, Name: "x"}, {ID: 3, Name: "c"}}, []string{"id"}); err != nil {
+		t.Fatal(err)
+	}
+	docs := allDocs(t, c)
+	if d := docs[1]; d.Name != "a" || d.TenantID != "t1" {
+		t.Fatalf("t1 row changed: %+v", d)
+	}
+	if d := docs[3]; d.TenantID != "t2" {
+		t.Fatalf("new row tenant = %q, want t2", d.TenantID)
+	}
+	if _, err := repo.FindByID(WithTenant(context.Background(), "t1"), 1); err != nil {
+		t.Fatalf("t1 lookup: %v", err)
+	}
+}
+
+func TestTenantUpsertUnsupportedDialect(t *testing.T) {
+	db, err := gorm.Open(tenantDialector{Dialector: mustDialector(t)}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registerTenantCallbacks(db, TenantConfig{Mode: TenantRow}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithTenant(context.Background(), "t1")
+	tests := []struct {
+		name    string
+		run     func(db *gorm.DB) error
+		wantErr error
+	}{
+		{"update all", func(db *gorm.DB) error {
+			return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&tenantDoc{ID: 1}).Error
+		}, ErrTenantUpsert},
+		{"do nothing", func(db *gorm.DB) error {
+			return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tenantDoc{ID: 1}).Error
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(db.WithContext(ctx)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// tenantDialector 以 mysql 名义复用 SQLite 方言，模拟不支持冲突更新条件的方言
+type tenantDialector struct{ gorm.Dialector }
+
+func (tenantDialector) Name() string { return "mysql" }
+
+func mustDialector(t *testing.T) gorm.Dialector {
+	t.Helper()
+	d, err := openDialector("sqlite", "file:"+filepath.Join(t.TempDir(), "dry.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func newSchemaClient(t *testing.T, tenant TenantConfig) *Client {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Driver = "sqlite"
+	cfg.DSN = "file:" + filepath.Join(t.TempDir(), "default.db")
+	cfg.Metrics = false
+	tenant.Mode = TenantSchema
+	tenant.DSN = "file:" + filepath.Join(t.TempDir(), "{tenant}.db")
+	cfg.Tenant = tenant
+	c, err := NewClient(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestTenantPoolsResolve(t *testing.T) {
+	c := newSchemaClient(t, TenantConfig{
+		Allow: []string{"t1"},
+		Resolve: func(_ context.Context, tenant string) (bool, error) {
+			if tenant == "broken" {
+				return false, errors.New("registry down")
+			}
+			return tenant == "t2", nil
+		},
+	})
+	tests := []struct {
+		tenant  string
+		wantErr bool
+		is      error
+	}{
+		{"t1", false, nil},
+		{"t2", false, nil},
+		{"t3", true, ErrUnknownTenant},
+		{"../t1", true, nil},
+		{"broken", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tenant, func(t *testing.T) {
+			err := c.GetDB(WithTenant(context.Background(), tt.tenant)).Exec("SELECT 1").Error
+			if (err != nil) != tt.wantErr || (tt.is != nil && !errors.Is(err, tt.is)) {
+				t.Fatalf("err = %v, want error %v (%v)", err, tt.wantErr, tt.is)
+			}
+		})
+	}
+	if n := len(c.tenants.clients); n != 2 {
+		t.Fatalf("open pools = %d, want 2", n)
+	}
+}
+
+func TestTenantPoolsRequireAllowList(t *testing.T) {
+	_, err := newTenantPools("db", Config{Tenant: TenantConfig{DSN: "{tenant}"}}, nil)
+	if err == nil {
+		t.Fatal("want error without allow or resolve")
+	}
+}
+
+func TestTenantPoolsEvict(t *testing.T) {
+	c := newSchemaClient(t, TenantConfig{Allow: []string{"t1", "t2", "t3"}, MaxPools: 2})
+	get := func(tenant string) *Client {
+		t.Helper()
+		tc, err := c.tenants.get(context.Background(), tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tc
+	}
+	t1 := get("t1")
+	get("t2")
+	if get("t1") != t1 {
+		t.Fatal("t1 pool was not reused")
+	}
+	get("t3") // 淘汰最久未使用的 t2
+
+	p := c.tenants
+	if _, ok := p.clients["t2"]; ok || len(p.clients) != 2 {
+		t.Fatalf("pools = %v, want t1 and t3", p.clients)
+	}
+	if len(p.evicted) != 1 {
+		t.Fatalf("evicted = %d, want 1", len(p.evicted))
+	}
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.clients) != 0 || len(p.evicted) != 0 || t1.Ready() {
+		t.Fatal("pools not closed")
+	}
+}
+
+func TestTenantPoolsSlowDial(t *testing.T) {
+	c := newSchemaClient(t, TenantConfig{Allow: []string{"t1", "slow"}})
+	p := c.tenants
+	release := make(chan struct{})
+	var dials atomic.Int32
+	open := p.open
+	p.open = func(tenant string) (*Client, error) {
+		if tenant == "slow" {
+			dials.Add(1)
+			<-release
+		}
+		return open(tenant)
+	}
+
+	// 等待中的请求按自身 ctx 超时返回
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.get(ctx, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+
+	// 慢租户建立期间，其他租户不受影响
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.get(context.Background(), "t1")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("t1 blocked by slow tenant")
+	}
+
+	var wg sync.WaitGroup
+	clients := make([]*Client, 3)
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clients[i], _ = p.get(context.Background(), "slow")
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if clients[0] == nil || clients[1] != clients[0] || clients[2] != clients[0] {
+		t.Fatalf("clients = %v, want one shared pool", clients)
+	}
+	if n := dials.Load(); n != 1 {
+		t.Fatalf("dials = %d, want 1", n)
+	}
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	timeoutCtxKey    = "kit:timeout_ctx"
+	timeoutCancelKey = "kit:timeout_cancel"
+	timeoutHintKey   = "kit:timeout_hint"
+)
+
+// ErrQueryTimeout 语句超时，可通过 errors.Is 判断；具体信息见 *TimeoutError
+var ErrQueryTimeout = errors.New("db: query timeout")
+
+// TimeoutError 语句因 ctx 截止时间、查询超时或数据库端执行时间限制而中断
+type TimeoutError struct {
+	Timeout time.Duration // 生效的查询超时，沿用 ctx 自带截止时间时为 0
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("db: query timeout after %s: %v", e.Timeout, e.Err)
+	}
+	return fmt.Sprintf("db: query timeout: %v", e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrQueryTimeout
+}
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout 覆盖之后通过 GetDB(ctx) 发起的语句的超时 (Config.QueryTimeout)，d <= 0 表示不限制。
+// ctx 自带更早的截止时间时以 ctx 为准
+func WithQueryTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, d)
+}
+
+// registerTimeoutCallbacks 语句执行前为没有截止时间的 ctx 套上查询超时，执行后恢复原 ctx 并统一超时错误。
+// MySQL 的 SELECT 额外追加 MAX_EXECUTION_TIME 提示，客户端放弃后服务端也会中止查询
+func registerTimeoutCallbacks(db *gorm.DB, timeout time.Duration) error {
+	t := &timeouts{def: timeout, mysql: db.Dialector.Name() == "mysql"}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("kit:timeout_start", t.start),
+		cb.Create().After("gorm:create").Register("kit:timeout_end", t.end),
+		cb.Query().Before("gorm:query").Register("kit:timeout_start", t.startQuery),
+		cb.Query().After("gorm:query").Register("kit:timeout_end", t.end),
+		cb.Update().Before("gorm:update").Register("kit:timeout_start", t.start),
+		cb.Update().After("gorm:update").Register("kit:timeout_end", t.end),
+		cb.Delete().Before("gorm:delete").Register("kit:timeout_start", t.start),
+		cb.Delete().After("gorm:delete").Register("kit:timeout_end", t.end),
+		cb.Raw().Before("gorm:raw").Register("kit:timeout_start", t.start),
+		cb.Raw().After("gorm:raw").Register("kit:timeout_end", t.end),
+		cb.Row().Before("gorm:row").Register("kit:timeout_start", t.startQuery),
+		cb.Row().After("gorm:row").Register("kit:timeout_end", t.endRow),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type timeouts struct {
+	def   time.Duration
+	mysql bool
+}
+
+// effective 返回本次语句的超时，0 表示不限制
+func (t *timeouts) effective(ctx context.Context) time.Duration {
+	if d, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok {
+		return max(d, 0)
+	}
+	if _, ok := ctx.Deadline(); ok {
+		// 已有截止时间 (如请求级超时) 时不再叠加默认值
+		return 0
+	}
+	return t.def
+}
+
+// start 同一 Statement 可能被复用，每次执行前都重置实例状态
+func (t *timeouts) start(tx *gorm.DB) {
+	tx.InstanceSet(timeoutCtxKey, nil)
+	tx.InstanceSet(timeoutHintKey, false)
+	if tx.Error != nil || tx.DryRun {
+		return
+	}
+	ctx := tx.Statement.Context
+	if d := t.effective(ctx); d > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, d)
+		tx.InstanceSet(timeoutCtxKey, ctx)
+		tx.InstanceSet(timeoutCancelKey, cancel)
+		tx.Statement.Context = timeoutCtx
+	}
+}
+
+// startQuery MySQL 下为 Gorm 构建的 SELECT 追加 MAX_EXECUTION_TIME 提示，Raw SQL 不做改写。
+// 提示值会进入 SQL 文本，为避免预编译语句缓存按剩余时间膨胀，使用生效的查询超时；
+// 沿用 ctx 自带截止时间时按 hintBucket 向上取整，客户端仍在截止时间到达时取消
+func (t *timeouts) startQuery(tx *gorm.DB) {
+	t.start(tx)
+	if !t.mysql || tx.Error != nil || tx.DryRun || tx.Statement.SQL.Len() > 0 {
+		return
+	}
+	var d time.Duration
+	if v, _ := tx.InstanceGet(timeoutCtxKey); v != nil {
+		d = t.effective(v.(context.Context))
+	} else if deadline, ok := tx.Statement.Context.Deadline(); ok {
+		d = hintBucket(time.Until(deadline))
+	} else {
+		return
+	}
+	c := tx.Statement.Clauses["SELECT"]
+	c.AfterNameExpression = clause.Expr{SQL: "/*+ MAX_EXECUTION_TIME(" + strconv.FormatInt(max(d.Milliseconds(), 1), 10) + ") */"}
+	tx.Statement.Clauses["SELECT"] = c
+	tx.InstanceSet(timeoutHintKey, true)
+}
+
+// hintBucket 将剩余时间向上取整到 2 的幂秒 (1s, 2s, 4s ... 不超过约 36h)，不同截止时间只产生少量不同的 SQL
+func hintBucket(d time.Duration) time.Duration {
+	b := time.Second
+	for b < d && b < 24*time.Hour {
+		b *= 2
+	}
+	return b
+}
+
+func (t *timeouts) end(tx *gorm.DB) {
+	t.finish(tx, true)
+}
+
+// endRow Row/Rows 返回后调用方仍需读取结果集，不能提前取消 ctx，由超时计时器到期释放
+func (t *timeouts) endRow(tx *gorm.DB) {
+	t.finish(tx, false)
+}
+
+func (t *timeouts) finish(tx *gorm.DB, cancel bool) {
+	if hinted, _ := tx.InstanceGet(timeoutHintKey); hinted == true {
+		c := tx.Statement.Clauses["SELECT"]
+		c.AfterNameExpression = nil
+		tx.Statement.Clauses["SELECT"] = c
+	}
+	if v, _ := tx.InstanceGet(timeoutCtxKey); v != nil {
+		orig := v.(context.Context)
+		if isTimeout(tx.Statement.Context, tx.Error) {
+			tx.Error = &TimeoutError{Timeout: t.effective(orig), Err: tx.Error}
+		}
+		// Statement 可能被复用，恢复为调用方的 ctx
+		tx.Statement.Context = orig
+		if fn, ok := tx.InstanceGet(timeoutCancelKey); ok && cancel {
+			fn.(context.CancelFunc)()
+		}
+		return
+	}
+	if isTimeout(tx.Statement.Context, tx.Error) {
+		tx.Error = &TimeoutError{Err: tx.Error}
+	}
+}
+
+// isTimeout 语句执行失败且 ctx 截止时间已到，或错误本身为超时
+func isTimeout(ctx context.Context, err error) bool {
+	if err == nil || errors.Is(err, ErrQueryTimeout) {
+		return false
+	}
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) || IsTimeout(err)
+}
+
+// IsTimeout 判断错误是否为查询超时。Rows / Scan 等在回调之外读取结果集时出现的超时不会被包装为 *TimeoutError，
+// HTTP / gRPC 层应使用该函数而非只判断 ErrQueryTimeout
+//   - ctx 截止时间已到 (context.DeadlineExceeded)
+//   - MySQL: 3024 超过 MAX_EXECUTION_TIME
+//   - PostgreSQL: 57014 statement_timeout
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrQueryTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == 3024
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "57014"
+	}
+	return false
+}
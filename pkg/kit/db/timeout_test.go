@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordPool 记录预编译的 SQL 后返回错误，无需真实数据库
+type recordPool struct {
+	mu  sync.Mutex
+	sql []string
+}
+
+var errRecorded = errors.New("recorded")
+
+func (p *recordPool) PrepareContext(_ context.Context, query string) (*sql.Stmt, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sql = append(p.sql, query)
+	return nil, errRecorded
+}
+
+func (p *recordPool) ExecContext(context.Context, string, ...any) (sql.Result, error) {
+	return nil, errRecorded
+}
+
+func (p *recordPool) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, errRecorded
+}
+
+func (p *recordPool) QueryRowContext(context.Context, string, ...any) *sql.Row {
+	return nil
+}
+
+func TestHintBucket(t *testing.T) {
+	tests := []struct {
+		in, want time.Duration
+	}{
+		{0, time.Second},
+		{time.Millisecond, time.Second},
+		{time.Second, time.Second},
+		{1500 * time.Millisecond, 2 * time.Second},
+		{3 * time.Second, 4 * time.Second},
+		{10 * time.Second, 16 * time.Second},
+		{1000 * time.Hour, 131072 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := hintBucket(tt.in); got != tt.want {
+			t.Errorf("hintBucket(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMaxExecutionTimeHintStable(t *testing.T) {
+	pool := &recordPool{}
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: pool, SkipInitializeWithVersion: true}), &gorm.Config{PrepareStmt: true, DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registerTimeoutCallbacks(db, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	hint := regexp.MustCompile(`MAX_EXECUTION_TIME\((\d+)\)`)
+	tests := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+		want string
+	}{
+		{"default timeout", func() (context.Context, context.CancelFunc) {
+			return context.Background(), func() {}
+		}, "10000"},
+		{"override", func() (context.Context, context.CancelFunc) {
+			return WithQueryTimeout(context.Background(), 3*time.Second), func() {}
+		}, "3000"},
+		{"ctx deadline", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 2500*time.Millisecond+time.Duration(time.Now().UnixNano()%int64(time.Second)))
+		}, "4000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool.sql = nil
+			for range 5 {
+				ctx, cancel := tt.ctx()
+				var out []tenantDoc
+				_ = db.WithContext(ctx).Where("id = ?", 1).Find(&out).Error
+				cancel()
+				time.Sleep(time.Millisecond)
+			}
+			if len(pool.sql) != 5 {
+				t.Fatalf("recorded %d statements, want 5", len(pool.sql))
+			}
+			for _, q := range pool.sql {
+				if q != pool.sql[0] {
+					t.Fatalf("SQL differs across runs:\n%s\n%s", pool.sql[0], q)
+				}
+			}
+			if m := hint.FindStringSubmatch(pool.sql[0]); m == nil || m[1] != tt.want {
+				t.Fatalf("hint in %q, want MAX_EXECUTION_TIME(%s)", pool.sql[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// ErrTxExists PropagationNever 遇到已存在的事务时返回
+var ErrTxExists = errors.New("db: transaction already exists")
+
+// Propagation 事务传播行为，语义与 Spring 保持一致
+type Propagation int
+
+const (
+	// PropagationRequired 有事务则加入，没有则新建 (默认)
+	PropagationRequired Propagation = iota
+	// PropagationRequiresNew 总是在新连接上开启独立事务，与外层事务互不影响
+	PropagationRequiresNew
+	// PropagationNested 有事务则创建 SavePoint，内层回滚不影响外层；没有则新建
+	PropagationNested
+	// PropagationSupports 有事务则加入，没有则以非事务方式执行
+	PropagationSupports
+	// PropagationNever 以非事务方式执行，存在事务则返回 ErrTxExists
+	PropagationNever
+)
+
+type txOptions struct {
+	propagation Propagation
+	isolation   sql.IsolationLevel
+	readOnly    bool
+	retry       *RetryPolicy
+}
+
+// TxOption WithTx 的可选参数
+type TxOption func(*txOptions)
+
+// WithPropagation 指定事务传播行为
+func WithPropagation(p Propagation) TxOption {
+	return func(o *txOptions) { o.propagation = p }
+}
+
+// WithIsolation 指定事务隔离级别，仅在新建事务时生效
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *txOptions) { o.isolation = level }
+}
+
+// WithReadOnly 开启只读事务，仅在新建事务时生效
+func WithReadOnly() TxOption {
+	return func(o *txOptions) { o.readOnly = true }
+}
+
+// TxFromContext 返回 ctx 绑定的事务连接，ctx 不在事务中时 ok 为 false
+func TxFromContext(ctx context.Context) (tx *gorm.DB, ok bool) {
+	s, ok := scopeFrom(ctx)
+	if !ok {
+		return nil, false
+	}
+	return s.db, true
+}
+
+// WithTx 在事务中执行 fn，fn 内通过 GetDB(ctx) 获取的连接自动绑定到该事务
+func (c *Client) WithTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error {
+	o := txOptions{propagation: PropagationRequired}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	current, inTx := scopeFrom(ctx)
+
+	switch o.propagation {
+	case PropagationRequired:
+		if inTx {
+			return fn(ctx)
+		}
+		return c.beginNew(ctx, fn, o)
+	case PropagationRequiresNew:
+		return c.beginNew(ctx, fn, o)
+	case PropagationNested:
+		if inTx {
+			// Gorm 在已有事务上调用 Transaction 时会自动使用 SavePoint
+			return c.begin(ctx, current, fn, o)
+		}
+		return c.beginNew(ctx, fn, o)
+	case PropagationSupports:
+		return fn(ctx)
+	case PropagationNever:
+		if inTx {
+			return ErrTxExists
+		}
+		return fn(ctx)
+	default:
+		return errors.New("db: unknown transaction propagation")
+	}
+}
+
+// beginNew 在根连接池上开启新事务，确保拿到一条新连接而不是外层事务的连接
+func (c *Client) beginNew(ctx context.Context, fn func(ctx context.Context) error, o txOptions) error {
+	run := func() error { return c.begin(ctx, nil, fn, o) }
+	if o.retry == nil {
+		return run()
+	}
+	return c.withRetry(ctx, *o.retry, run)
+}
+
+// begin 开启事务；parent 不为空时在其连接上创建 SavePoint
+func (c *Client) begin(ctx context.Context, parent *txScope, fn func(ctx context.Context) error, o txOptions) (err error) {
+	var db *gorm.DB
+	if parent != nil {
+		db = parent.db
+	} else {
+		root, err := c.root(ctx)
+		if err != nil {
+			return err
+		}
+		db = root.db.WithContext(ctx)
+	}
+	var sqlOpts []*sql.TxOptions
+	if o.isolation != sql.LevelDefault || o.readOnly {
+		sqlOpts = append(sqlOpts, &sql.TxOptions{Isolation: o.isolation, ReadOnly: o.readOnly})
+	}
+
+	scope := &txScope{parent: parent}
+	defer func() {
+		// fn panic 时 Gorm 已回滚，触发回滚回调后继续向上抛出
+		if r := recover(); r != nil {
+			scope.complete(false)
+			panic(r)
+		}
+		scope.complete(err == nil)
+	}()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		scope.db = tx
+		return fn(context.WithValue(ctx, txKey{}, scope))
+	}, sqlOpts...)
+}
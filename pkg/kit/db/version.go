@@ -0,0 +1,134 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrStaleObject 乐观锁冲突：记录已被其他请求修改 (或已删除)，调用方应重新读取后再更新
+var ErrStaleObject = errors.New("db: stale object, the record has been modified or deleted")
+
+// Version 乐观锁版本号，实体声明该类型字段即开启乐观锁 (opt-in)。
+// 新建记录时版本号为 0 会自动置为 1；通过 Model(entity) 更新时追加 WHERE version = 当前值
+// 并将版本号加 1，未命中任何行时返回 ErrStaleObject，命中后实体上的版本号同步更新
+type Version int64
+
+const (
+	versionCheckedKey = "kit:version_checked"
+	versionSetKey     = "kit:version_set"
+)
+
+var versionType = reflect.TypeOf(Version(0))
+
+// versionField 查找实体的乐观锁字段
+func versionField(s *schema.Schema) *schema.Field {
+	if s == nil {
+		return nil
+	}
+	for _, f := range s.Fields {
+		if f.FieldType == versionType && f.DBName != "" {
+			return f
+		}
+	}
+	return nil
+}
+
+func registerVersionCallbacks(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("kit:version_init", initVersion),
+		cb.Update().Before("gorm:update").Register("kit:version_lock", lockVersion),
+		cb.Update().After("gorm:update").Register("kit:version_check", checkVersion),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initVersion 新建记录的版本号从 1 开始
+func initVersion(tx *gorm.DB) {
+	stmt := tx.Statement
+	field := versionField(stmt.Schema)
+	if tx.Error != nil || field == nil {
+		return
+	}
+	set := func(rv reflect.Value) {
+		if _, zero := field.ValueOf(stmt.Context, rv); zero {
+			tx.AddError(field.Set(stmt.Context, rv, Version(1)))
+		}
+	}
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Struct:
+		set(rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				set(elem)
+			}
+		}
+	}
+}
+
+// lockVersion 追加版本条件并将版本号加 1。
+// 仅当更新目标是携带非零版本号的单个实体时才校验版本，批量更新只递增版本号
+func lockVersion(tx *gorm.DB) {
+	stmt := tx.Statement
+	field := versionField(stmt.Schema)
+	if tx.Error != nil || field == nil || stmt.Unscoped || stmt.SQL.Len() > 0 {
+		return
+	}
+	if _, ok := stmt.Clauses["SET"]; ok {
+		return
+	}
+
+	col := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	if rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() == reflect.Struct {
+		if current, zero := field.ValueOf(stmt.Context, rv); !zero {
+			stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: col, Value: current}}})
+			stmt.Settings.Store(versionCheckedKey, current)
+		}
+	}
+
+	// 提前生成 SET 子句以便追加 version = version + 1，gorm:update 检测到 SET 后不会再次生成
+	set := callbacks.ConvertToAssignments(stmt)
+	if tx.Error != nil || len(set) == 0 {
+		return
+	}
+	assignments := set[:0]
+	for _, a := range set {
+		if a.Column.Name != field.DBName {
+			assignments = append(assignments, a)
+		}
+	}
+	assignments = append(assignments, clause.Assignment{
+		Column: clause.Column{Name: field.DBName},
+		Value:  clause.Expr{SQL: "? + 1", Vars: []any{clause.Column{Name: field.DBName}}},
+	})
+	stmt.AddClause(assignments)
+	stmt.Settings.Store(versionSetKey, true)
+}
+
+// checkVersion 未命中任何行说明版本已变化，命中后同步实体上的版本号
+func checkVersion(tx *gorm.DB) {
+	stmt := tx.Statement
+	if _, ok := stmt.Settings.LoadAndDelete(versionSetKey); ok {
+		delete(stmt.Clauses, "SET")
+	}
+	current, ok := stmt.Settings.LoadAndDelete(versionCheckedKey)
+	if tx.Error != nil || !ok || tx.DryRun {
+		return
+	}
+	if tx.RowsAffected == 0 {
+		tx.AddError(ErrStaleObject)
+		return
+	}
+	field := versionField(stmt.Schema)
+	tx.AddError(field.Set(stmt.Context, reflect.Indirect(stmt.ReflectValue), current.(Version)+1))
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type versionDoc struct {
+	ID      int64 `gorm:"primaryKey"`
+	Name    string
+	Version Version
+}
+
+// newVersionClient 创建 SQLite Client，并写入 ID 为 1 的记录
+func newVersionClient(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Driver = "sqlite"
+	cfg.DSN = "file:" + filepath.Join(t.TempDir(), "version.db")
+	cfg.Metrics = false
+	c, err := NewClient(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	db := c.GetDB(context.Background())
+	if err := db.AutoMigrate(&versionDoc{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&versionDoc{ID: 1, Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestVersionInit(t *testing.T) {
+	tests := []struct {
+		name string
+		docs []versionDoc
+		want []Version
+	}{
+		{"zero starts at one", []versionDoc{{ID: 2}}, []Version{1}},
+		{"explicit kept", []versionDoc{{ID: 2, Version: 5}}, []Version{5}},
+		{"batch", []versionDoc{{ID: 2}, {ID: 3, Version: 4}, {ID: 4}}, []Version{1, 4, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newVersionClient(t)
+			if err := db.Create(&tt.docs).Error; err != nil {
+				t.Fatal(err)
+			}
+			for i, d := range tt.docs {
+				var stored versionDoc
+				if err := db.First(&stored, d.ID).Error; err != nil {
+					t.Fatal(err)
+				}
+				if d.Version != tt.want[i] || stored.Version != tt.want[i] {
+					t.Fatalf("doc %d version = %d (stored %d), want %d", d.ID, d.Version, stored.Version, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVersionLock(t *testing.T) {
+	tests := []struct {
+		name        string
+		update      func(db *gorm.DB, doc *versionDoc) error
+		wantErr     error
+		wantVersion Version // 实体上的版本号
+		wantStored  Version // 库中的版本号
+	}{
+		{"updates", func(db *gorm.DB, doc *versionDoc) error {
+			return db.Model(doc).Updates(map[string]any{"name": "b"}).Error
+		}, nil, 2, 2},
+		{"update column", func(db *gorm.DB, doc *versionDoc) error {
+			return db.Model(doc).Update("name", "b").Error
+		}, nil, 2, 2},
+		{"save", func(db *gorm.DB, doc *versionDoc) error {
+			doc.Name = "b"
+			return db.Save(doc).Error
+		}, nil, 2, 2},
+		{"version in payload ignored", func(db *gorm.DB, doc *versionDoc) error {
+			return db.Model(doc).Updates(map[string]any{"name": "b", "version": 10}).Error
+		}, nil, 2, 2},
+		{"stale", func(db *gorm.DB, doc *versionDoc) error {
+			if err := db.Model(&versionDoc{ID: 1, Version: 1}).Update("name", "other").Error; err != nil {
+				return err
+			}
+			return db.Model(doc).Update("name", "b").Error
+		}, ErrStaleObject, 1, 2},
+		{"stale save does not insert", func(db *gorm.DB, doc *versionDoc) error {
+			if err := db.Model(&versionDoc{ID: 1, Version: 1}).Update("name", "other").Error; err != nil {
+				return err
+			}
+			doc.Name = "b"
+			return db.Save(doc).Error
+		}, ErrStaleObject, 1, 2},
+		{"deleted", func(db *gorm.DB, doc *versionDoc) error {
+			if err := db.Delete(&versionDoc{}, 1).Error; err != nil {
+				return err
+			}
+			return db.Model(doc).Update("name", "b").Error
+		}, ErrStaleObject, 1, 0},
+		{"batch increments without check", func(db *gorm.DB, doc *versionDoc) error {
+			return db.Model(&versionDoc{}).Where("id = ?", 1).Update("name", "b").Error
+		}, nil, 1, 2},
+		{"batch without match is not stale", func(db *gorm.DB, doc *versionDoc) error {
+			return db.Model(&versionDoc{}).Where("id = ?", 99).Update("name", "b").Error
+		}, nil, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newVersionClient(t)
+			var doc versionDoc
+			if err := db.First(&doc, 1).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.update(db, &doc); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if doc.Version != tt.wantVersion {
+				t.Fatalf("entity version = %d, want %d", doc.Version, tt.wantVersion)
+			}
+			var stored versionDoc
+			if err := db.Where("id = ?", 1).Limit(1).Find(&stored).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored.Version != tt.wantStored {
+				t.Fatalf("stored version = %d, want %d", stored.Version, tt.wantStored)
+			}
+			var count int64
+			if err := db.Model(&versionDoc{}).Count(&count).Error; err != nil {
+				t.Fatal(err)
+			}
+			// 版本冲突时 Save 不应回退为插入
+			want := int64(1)
+			if tt.wantStored == 0 {
+				want = 0
+			}
+			if count != want {
+				t.Fatalf("count = %d, want %d", count, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Package health 组件健康状态注册表，HTTP (/readyz) 与 gRPC (grpc.health.v1) 健康检查接口从这里读取。
+package health
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout 单个组件检查的默认超时
+const DefaultTimeout = 2 * time.Second
+
+// Status 健康状态
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Checker 组件检查函数，返回 nil 表示可用
+type Checker func(ctx context.Context) error
+
+// Component 单个组件的检查结果
+type Component struct {
+	Status  Status        `json:"status"`
+	Error   string        `json:"error,omitempty"`
+	Latency time.Duration `json:"latency"`
+}
+
+// Report 汇总结果，任一组件不可用时整体为 down
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+func (r Report) Healthy() bool {
+	return r.Status == StatusUp
+}
+
+// Registry 健康检查注册表，并发安全
+type Registry struct {
+	mu      sync.RWMutex
+	checks  map[string]Checker
+	timeout time.Duration
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checks: map[string]Checker{}, timeout: DefaultTimeout}
+}
+
+// Register 注册组件检查，同名覆盖
+func (r *Registry) Register(name string, check Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check
+}
+
+// Unregister 移除组件检查
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Check 并发执行全部组件检查
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := maps.Clone(r.checks)
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Go(func() {
+			c := r.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = c
+			if c.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		})
+	}
+	wg.Wait()
+	return report
+}
+
+// CheckOne 执行单个组件检查，组件未注册时 ok 为 false
+func (r *Registry) CheckOne(ctx context.Context, name string) (c Component, ok bool) {
+	r.mu.RLock()
+	check, ok := r.checks[name]
+	r.mu.RUnlock()
+	if !ok {
+		return Component{}, false
+	}
+	return r.run(ctx, check), true
+}
+
+func (r *Registry) run(ctx context.Context, check Checker) Component {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	start := time.Now()
+	err := check(ctx)
+	c := Component{Status: StatusUp, Latency: time.Since(start)}
+	if err != nil {
+		c.Status, c.Error = StatusDown, err.Error()
+	}
+	return c
+}
@@ -0,0 +1,42 @@
+package lock
+
+import "time"
+
+type Config struct {
+	Mode          string        `mapstructure:"mode"`           // auto: MySQL/PostgreSQL 使用会话锁，其他驱动使用租约表；native；lease
+	Table         string        `mapstructure:"table"`          // 租约表
+	TTL           time.Duration `mapstructure:"ttl"`            // 租约时长，持有者失联超过该时长后锁可被他人获取
+	RenewInterval time.Duration `mapstructure:"renew_interval"` // 续约间隔 (会话锁为连接保活间隔)，默认 TTL/3
+	RetryInterval time.Duration `mapstructure:"retry_interval"` // Lock 等待与选主重试的轮询间隔
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Mode:          "auto",
+		Table:         "kit_locks",
+		TTL:           30 * time.Second,
+		RenewInterval: 10 * time.Second,
+		RetryInterval: time.Second,
+	}
+}
+
+// withDefaults 未配置的字段使用默认值
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.Mode == "" {
+		c.Mode = def.Mode
+	}
+	if c.Table == "" {
+		c.Table = def.Table
+	}
+	if c.TTL <= 0 {
+		c.TTL = def.TTL
+	}
+	if c.RenewInterval <= 0 || c.RenewInterval >= c.TTL {
+		c.RenewInterval = c.TTL / 3
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = def.RetryInterval
+	}
+	return c
+}
@@ -0,0 +1,119 @@
+package lock
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// Callbacks 选主回调
+type Callbacks struct {
+	// OnElected 当选后在独立 goroutine 中调用，失去领导权或服务停止时 ctx 被取消，回调应随之返回
+	OnElected func(ctx context.Context)
+	// OnRevoked 失去领导权后调用 (此时 OnElected 已返回、锁已释放)
+	OnRevoked func()
+}
+
+// Election 基于锁的选主：多个实例竞争同名锁，持有者即为 leader，锁丢失后自动重新竞选
+type Election struct {
+	locker *Locker
+	name   string
+	cb     Callbacks
+	leader atomic.Bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewElection 创建选主，通过 StartElection 挂载到 fx 生命周期
+func (l *Locker) NewElection(name string, cb Callbacks) *Election {
+	return &Election{locker: l, name: name, cb: cb, done: make(chan struct{})}
+}
+
+// IsLeader 当前实例是否为 leader
+func (e *Election) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// StartElection 生命周期管理：启动后开始竞选，停止时让出领导权
+//
+//	fx.Invoke(func(lc fx.Lifecycle, locker *lock.Locker, job *CleanupJob) {
+//		lock.StartElection(lc, locker.NewElection("cleanup", lock.Callbacks{OnElected: job.Run}))
+//	})
+func StartElection(lc fx.Lifecycle, e *Election) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			runCtx, cancel := context.WithCancel(context.Background())
+			e.cancel = cancel
+			go e.run(runCtx)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			e.cancel()
+			select {
+			case <-e.done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+func (e *Election) run(ctx context.Context) {
+	defer close(e.done)
+	for {
+		k, err := e.locker.Lock(ctx, e.name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			e.locker.l.Warn("leader_campaign_failed", slog.String("name", e.name), slog.Any("err", err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(e.locker.cfg.RetryInterval):
+			}
+			continue
+		}
+		e.lead(ctx, k)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// lead 持有锁期间运行 OnElected，直到锁丢失或服务停止
+func (e *Election) lead(ctx context.Context, k *Lock) {
+	e.leader.Store(true)
+	e.locker.l.Info("leader_elected", slog.String("name", e.name))
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		if e.cb.OnElected != nil {
+			e.cb.OnElected(leadCtx)
+		}
+	}()
+	select {
+	case <-ctx.Done():
+	case <-k.Lost():
+	}
+	cancel()
+	<-finished
+
+	e.leader.Store(false)
+	unlockCtx, unlockCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := k.Unlock(unlockCtx); err != nil {
+		e.locker.l.Warn("lock_release_failed", slog.String("name", e.name), slog.Any("err", err))
+	}
+	unlockCancel()
+	e.locker.l.Info("leader_revoked", slog.String("name", e.name))
+	if e.cb.OnRevoked != nil {
+		e.cb.OnRevoked()
+	}
+}
@@ -0,0 +1,317 @@
+// Package lock 基于数据库的分布式锁与选主，用于多实例部署时让任务只在一个实例上运行。
+// MySQL 使用 GET_LOCK，PostgreSQL 使用 pg_try_advisory_lock，锁与独占的数据库连接绑定，连接断开即释放；
+// 其他驱动 (或 mode: lease) 使用租约表，持有者定期续约，超过 TTL 未续约的锁可被他人获取。
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"goKit/pkg/kit/db"
+	"goKit/pkg/kit/log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotAcquired 锁已被其他持有者占用
+	ErrNotAcquired = errors.New("lock: already held by another owner")
+	// ErrLost 持有期间锁丢失 (连接断开或租约被他人接管)
+	ErrLost = errors.New("lock: lost")
+)
+
+// lease 租约表记录
+type lease struct {
+	Name      string    `gorm:"primaryKey;size:191"`
+	Owner     string    `gorm:"size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
+
+// Locker 分布式锁工厂
+type Locker struct {
+	client  *db.Client
+	cfg     Config
+	l       *slog.Logger
+	dialect string
+	native  bool
+
+	once       sync.Once
+	migrateErr error
+}
+
+func New(client *db.Client, cfg Config, l *slog.Logger) (*Locker, error) {
+	cfg = cfg.withDefaults()
+	dialect := client.GetDB(context.Background()).Dialector.Name()
+	supported := dialect == "mysql" || dialect == "postgres"
+
+	var native bool
+	switch cfg.Mode {
+	case "auto":
+		native = supported
+	case "native":
+		if !supported {
+			return nil, fmt.Errorf("lock: native mode does not support %s", dialect)
+		}
+		native = true
+	case "lease":
+	default:
+		return nil, fmt.Errorf("lock: unknown mode %q", cfg.Mode)
+	}
+	return &Locker{client: client, cfg: cfg, l: log.NamedFrom(l, "lock"), dialect: dialect, native: native}, nil
+}
+
+// Lock 已持有的锁
+type Lock struct {
+	name      string
+	keepalive func(ctx context.Context) error
+	release   func(ctx context.Context) error
+	l         *slog.Logger
+
+	lost chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+	err  error
+}
+
+// Name 锁名
+func (k *Lock) Name() string {
+	return k.name
+}
+
+// Lost 锁丢失时关闭，持有锁执行的任务应监听该通道并尽快停止
+func (k *Lock) Lost() <-chan struct{} {
+	return k.lost
+}
+
+// Unlock 停止续约并释放锁，可重复调用
+func (k *Lock) Unlock(ctx context.Context) error {
+	k.once.Do(func() {
+		close(k.stop)
+		<-k.done
+		k.err = k.release(ctx)
+	})
+	return k.err
+}
+
+// TryLock 尝试获取锁，已被占用时立即返回 ErrNotAcquired。
+// 锁的获取与释放不加入 ctx 中的事务；获得的锁在后台自动续约，直到 Unlock 或丢失
+func (l *Locker) TryLock(ctx context.Context, name string) (*Lock, error) {
+	if l.native {
+		return l.tryNative(ctx, name)
+	}
+	return l.tryLease(ctx, name)
+}
+
+// Lock 获取锁，已被占用时按 RetryInterval 轮询等待，直到获得或 ctx 结束
+func (l *Locker) Lock(ctx context.Context, name string) (*Lock, error) {
+	for {
+		k, err := l.TryLock(ctx, name)
+		if !errors.Is(err, ErrNotAcquired) {
+			return k, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.cfg.RetryInterval):
+		}
+	}
+}
+
+// primary 返回主库会话，不复用 ctx 中绑定的事务；锁表属于公共库，不区分租户
+func (l *Locker) primary(ctx context.Context) *gorm.DB {
+	return l.client.GetDB(db.WithAllTenants(db.WithPrimary(context.Background()))).WithContext(ctx)
+}
+
+func (l *Locker) hold(name string, keepalive, release func(ctx context.Context) error) *Lock {
+	k := &Lock{
+		name:      name,
+		keepalive: keepalive,
+		release:   release,
+		l:         l.l,
+		lost:      make(chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go k.keep(l.cfg.RenewInterval, l.cfg.TTL)
+	return k
+}
+
+// keep 定期续约；续约失败时在租约到期前持续重试，到期或确认被接管后标记为丢失
+func (k *Lock) keep(interval, ttl time.Duration) {
+	defer close(k.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(ttl)
+	for {
+		select {
+		case <-k.stop:
+			return
+		case <-ticker.C:
+		}
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := k.keepalive(ctx)
+		cancel()
+		if err == nil {
+			deadline = start.Add(ttl)
+			continue
+		}
+		if errors.Is(err, ErrLost) || !time.Now().Before(deadline) {
+			k.l.Warn("lock_lost", slog.String("name", k.name), slog.Any("err", err))
+			close(k.lost)
+			return
+		}
+		k.l.Warn("lock_renew_failed", slog.String("name", k.name), slog.Any("err", err))
+	}
+}
+
+// tryNative 在独占连接上获取会话级锁，连接随锁一起释放
+func (l *Locker) tryNative(ctx context.Context, name string) (*Lock, error) {
+	sqlDB, err := l.primary(ctx).DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		acquired   bool
+		unlockSQL  string
+		unlockArgs []any
+	)
+	switch l.dialect {
+	case "mysql":
+		key := mysqlKey(name)
+		var got sql.NullInt64
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", key).Scan(&got)
+		acquired = got.Int64 == 1
+		unlockSQL, unlockArgs = "SELECT RELEASE_LOCK(?)", []any{key}
+	default:
+		key := pgKey(name)
+		err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired)
+		unlockSQL, unlockArgs = "SELECT pg_advisory_unlock($1)", []any{key}
+	}
+	if err == nil && !acquired {
+		err = ErrNotAcquired
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	keepalive := func(ctx context.Context) error {
+		if err := conn.PingContext(ctx); err != nil {
+			return errors.Join(ErrLost, err)
+		}
+		return nil
+	}
+	release := func(ctx context.Context) error {
+		defer conn.Close()
+		_, err := conn.ExecContext(ctx, unlockSQL, unlockArgs...)
+		return err
+	}
+	return l.hold(name, keepalive, release), nil
+}
+
+// mysqlKey GET_LOCK 的锁名最长 64 个字符，超长时取哈希
+func mysqlKey(name string) string {
+	key := "lock:" + name
+	if len(key) <= 64 {
+		return key
+	}
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return fmt.Sprintf("lock:%x", h.Sum64())
+}
+
+func pgKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("lock:" + name))
+	return int64(h.Sum64())
+}
+
+// leases 返回租约表会话，首次使用时建表
+func (l *Locker) leases(ctx context.Context) (*gorm.DB, error) {
+	l.once.Do(func() {
+		l.migrateErr = l.primary(context.Background()).Table(l.cfg.Table).AutoMigrate(&lease{})
+	})
+	if l.migrateErr != nil {
+		return nil, l.migrateErr
+	}
+	return l.primary(ctx).Table(l.cfg.Table), nil
+}
+
+// tryLease 插入租约；已存在时仅在租约过期后接管。过期判断使用各实例的本地时钟，需保证时钟同步
+func (l *Locker) tryLease(ctx context.Context, name string) (*Lock, error) {
+	tx, err := l.leases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owner := newOwner()
+	now := time.Now()
+	rec := lease{Name: name, Owner: owner, ExpiresAt: now.Add(l.cfg.TTL)}
+	res := tx.Session(&gorm.Session{}).Clauses(clause.OnConflict{DoNothing: true}).Create(&rec)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		res = tx.Session(&gorm.Session{}).
+			Where("name = ? AND expires_at < ?", name, now).
+			Updates(map[string]any{"owner": owner, "expires_at": rec.ExpiresAt})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil, ErrNotAcquired
+		}
+	}
+
+	keepalive := func(ctx context.Context) error {
+		tx, err := l.leases(ctx)
+		if err != nil {
+			return err
+		}
+		res := tx.Where("name = ? AND owner = ?", name, owner).Update("expires_at", time.Now().Add(l.cfg.TTL))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrLost
+		}
+		return nil
+	}
+	release := func(ctx context.Context) error {
+		tx, err := l.leases(ctx)
+		if err != nil {
+			return err
+		}
+		return tx.Where("name = ? AND owner = ?", name, owner).Delete(&lease{}).Error
+	}
+	return l.hold(name, keepalive, release), nil
+}
+
+// newOwner 生成持有者标识：主机名 + 进程号 + 随机后缀，便于排查锁被谁持有
+func newOwner() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	owner := fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+	if len(owner) > 64 {
+		owner = owner[len(owner)-64:]
+	}
+	return owner
+}
@@ -0,0 +1,8 @@
+package lock
+
+import "go.uber.org/fx"
+
+// Module 提供 *Locker，需额外 Provide Config
+var Module = fx.Options(
+	fx.Provide(New),
+)
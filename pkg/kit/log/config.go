@@ -1,16 +1,33 @@
 // pkg/kit/log/config.go
 package log
 
+import "time"
+
 type Config struct {
-	Level  string `mapstructure:"level" json:"level" yaml:"level"`    // debug, info, warn, error
-	Format string `mapstructure:"format" json:"format" yaml:"format"` // json, text
-	Source bool   `mapstructure:"source" json:"source" yaml:"source"` // 是否打印文件行号 (生产环境建议关闭提升性能)
+	Level   string         `mapstructure:"level" json:"level" yaml:"level"`       // debug, info, warn, error
+	Format  string         `mapstructure:"format" json:"format" yaml:"format"`    // json, text
+	Source  bool           `mapstructure:"source" json:"source" yaml:"source"`    // 是否打印文件行号 (生产环境建议关闭提升性能)
+	Outputs []OutputConfig `mapstructure:"outputs" json:"outputs" yaml:"outputs"` // 输出目标，为空时输出到 stdout
+	// 按组件 (component 属性，见 Named) 设置级别，如 db: warn；未配置的组件使用 Level
+	Levels map[string]string `mapstructure:"levels" json:"levels" yaml:"levels"`
+}
+
+// OutputConfig 日志输出目标，切割与清理配置只对 file 生效
+type OutputConfig struct {
+	Type       string        `mapstructure:"type" json:"type" yaml:"type"`                      // stdout, stderr, file
+	Path       string        `mapstructure:"path" json:"path" yaml:"path"`                      // 日志文件路径
+	MaxSize    int           `mapstructure:"max_size" json:"max_size" yaml:"max_size"`          // 单个文件上限 (MB)，0 表示不按大小切割
+	Daily      bool          `mapstructure:"daily" json:"daily" yaml:"daily"`                   // 跨天时切割
+	MaxAge     time.Duration `mapstructure:"max_age" json:"max_age" yaml:"max_age"`             // 切割后的文件保留时长 (从切割时算起)，0 表示不按时间清理
+	MaxBackups int           `mapstructure:"max_backups" json:"max_backups" yaml:"max_backups"` // 切割后的文件保留个数，0 表示不限制
+	Compress   bool          `mapstructure:"compress" json:"compress" yaml:"compress"`          // gzip 压缩切割后的文件
 }
 
 func DefaultConfig() Config {
 	return Config{
-		Level:  "info",
-		Format: "json",
-		Source: false,
+		Level:   "info",
+		Format:  "json",
+		Source:  false,
+		Outputs: []OutputConfig{{Type: "stdout"}},
 	}
 }
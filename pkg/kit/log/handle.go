@@ -8,22 +8,125 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// TraceKey 链路 ID 字段名
+const TraceKey = "trace_id"
+
+type (
+	traceIDKey struct{}
+	fieldsKey  struct{}
+)
+
+// WithTraceID 为 ctx 绑定链路 ID (如 HTTP 请求 ID)，使用该 ctx 记录的日志附带 trace_id。
+// ctx 中存在有效的 OpenTelemetry Span 时优先使用 Span 的 TraceID
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext 返回 ctx 的链路 ID：优先 OpenTelemetry Span，其次 WithTraceID
+func TraceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		return span.TraceID().String()
+	}
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// WithFields 为 ctx 追加请求级字段 (如 user_id、tenant)，使用该 ctx 记录的每条日志都会附带这些字段
+func WithFields(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	prev := fieldsFromContext(ctx)
+	fields := make([]slog.Attr, 0, len(prev)+len(attrs))
+	fields = append(fields, prev...)
+	fields = append(fields, attrs...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+func fieldsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	return fields
+}
+
+// TraceHandler 为日志注入 ctx 中的 trace_id 与 WithFields 字段。
+// With / WithGroup 返回的 Handler 仍是 TraceHandler，ctx 字段始终位于顶层而不会落入分组
 type TraceHandler struct {
-	slog.Handler
+	slog.Handler // 已应用 With / WithGroup 的 Handler
+
+	base    slog.Handler // 未应用 With / WithGroup 的 Handler，存在分组时用于重建
+	ops     []handlerOp
+	grouped bool
 }
 
-// Handle 重写 Handle 方法，自动注入 TraceID
+// handlerOp With 或 WithGroup 调用，group 非空表示分组
+type handlerOp struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewTraceHandler 包装 h
+func NewTraceHandler(h slog.Handler) *TraceHandler {
+	return &TraceHandler{Handler: h, base: h}
+}
+
+// Handle 注入 ctx 字段后交给内部 Handler
 func (h *TraceHandler) Handle(ctx context.Context, r slog.Record) error {
-	// 假设你的 TraceID Key 是 "trace_id" 或者 "request_id"
-	// 这里适配 Fiber 的 requestid 中间件通常使用的 key，或者你自定义的 key
-	if traceID, ok := ctx.Value("requestid").(string); ok && traceID != "" {
-		r.AddAttrs(slog.String("trace_id", traceID))
+	var attrs []slog.Attr
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		attrs = append(attrs, slog.String(TraceKey, traceID))
 	}
+	attrs = append(attrs, fieldsFromContext(ctx)...)
+	if len(attrs) == 0 {
+		return h.Handler.Handle(ctx, r)
+	}
+	if !h.grouped {
+		r.AddAttrs(attrs...)
+		return h.Handler.Handle(ctx, r)
+	}
+	// 存在分组时，Record 上的属性会落入最内层分组；先在顶层添加 ctx 字段，再重放 With 链
+	inner := h.root().WithAttrs(attrs)
+	for _, op := range h.ops {
+		if op.group != "" {
+			inner = inner.WithGroup(op.group)
+		} else {
+			inner = inner.WithAttrs(op.attrs)
+		}
+	}
+	return inner.Handle(ctx, r)
+}
 
-	// 如果你有 OpenTelemetry，也可以在这里从 spanContext 获取
-	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
-		r.AddAttrs(slog.String("trace_id", span.SpanContext().TraceID().String()))
+func (h *TraceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
 	}
+	return h.with(h.Handler.WithAttrs(attrs), handlerOp{attrs: attrs})
+}
+
+func (h *TraceHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := h.with(h.Handler.WithGroup(name), handlerOp{group: name})
+	next.grouped = true
+	return next
+}
 
-	return h.Handler.Handle(ctx, r)
+func (h *TraceHandler) with(inner slog.Handler, op handlerOp) *TraceHandler {
+	ops := make([]handlerOp, 0, len(h.ops)+1)
+	ops = append(ops, h.ops...)
+	return &TraceHandler{Handler: inner, base: h.root(), ops: append(ops, op), grouped: h.grouped}
+}
+
+// root 兼容直接以 &TraceHandler{Handler: h} 构造的情况
+func (h *TraceHandler) root() slog.Handler {
+	if h.base != nil {
+		return h.base
+	}
+	return h.Handler
 }
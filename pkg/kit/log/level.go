@@ -0,0 +1,267 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ComponentKey 日志器名称属性，携带该属性的 Logger 可以单独设置级别
+const ComponentKey = "component"
+
+// levels 全局日志级别与按名称的覆盖，运行期可修改
+var levels = newLevelState()
+
+// LevelOverride 按名称覆盖的级别
+type LevelOverride struct {
+	Name      string     `json:"name"`
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 到期后恢复修改前的级别
+}
+
+// LevelInfo 当前日志级别
+type LevelInfo struct {
+	Level     string          `json:"level"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Overrides []LevelOverride `json:"overrides"`
+}
+
+type override struct {
+	level     slog.Level
+	expiresAt time.Time
+}
+
+type levelState struct {
+	global slog.LevelVar
+	named  atomic.Pointer[map[string]override] // 写时复制，Enabled 无锁读取
+
+	mu sync.Mutex
+	// 每次修改递增，过期回滚只撤销自己那次修改
+	globalGen   uint64
+	namedGen    map[string]uint64
+	globalUntil time.Time
+	toggled     *slog.Level           // SIGUSR1 切换到 debug 前的级别
+	configured  map[string]slog.Level // log.levels 配置的组件级别，ResetNamedLevel 时恢复
+}
+
+func newLevelState() *levelState {
+	s := &levelState{namedGen: map[string]uint64{}, configured: map[string]slog.Level{}}
+	s.named.Store(&map[string]override{})
+	return s
+}
+
+func (s *levelState) enabled(name string, level slog.Level) bool {
+	if name != "" {
+		if o, ok := (*s.named.Load())[name]; ok {
+			return level >= o.level
+		}
+	}
+	return level >= s.global.Level()
+}
+
+// ParseLevel 解析 debug / info / warn / error，大小写不敏感
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("log: invalid level %q", s)
+	}
+	return level, nil
+}
+
+// Level 当前全局级别
+func Level() slog.Level {
+	return levels.global.Level()
+}
+
+// SetLevel 修改全局级别，ttl > 0 时到期后恢复修改前的级别
+func SetLevel(level slog.Level, ttl time.Duration) {
+	s := levels
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.toggled = nil
+	s.setGlobal(level, ttl)
+}
+
+func (s *levelState) setGlobal(level slog.Level, ttl time.Duration) {
+	prev := s.global.Level()
+	s.global.Set(level)
+	s.globalGen++
+	s.globalUntil = time.Time{}
+	L().Info("log_level_changed", slog.String("level", level.String()), slog.Duration("ttl", ttl))
+	if ttl <= 0 {
+		return
+	}
+	gen := s.globalGen
+	s.globalUntil = time.Now().Add(ttl)
+	time.AfterFunc(ttl, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.globalGen != gen {
+			return
+		}
+		s.global.Set(prev)
+		s.globalGen++
+		s.globalUntil = time.Time{}
+		L().Info("log_level_reverted", slog.String("level", prev.String()))
+	})
+}
+
+// SetNamedLevel 覆盖指定名称 (ComponentKey) 的级别，ttl > 0 时到期后恢复修改前的状态
+func SetNamedLevel(name string, level slog.Level, ttl time.Duration) {
+	s := levels
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, had := (*s.named.Load())[name]
+	o := override{level: level}
+	if ttl > 0 {
+		o.expiresAt = time.Now().Add(ttl)
+	}
+	s.putNamed(name, &o)
+	s.namedGen[name]++
+	L().Info("log_level_changed", slog.String("name", name), slog.String("level", level.String()), slog.Duration("ttl", ttl))
+	if ttl <= 0 {
+		return
+	}
+	gen := s.namedGen[name]
+	time.AfterFunc(ttl, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.namedGen[name] != gen {
+			return
+		}
+		if had {
+			s.putNamed(name, &prev)
+		} else {
+			s.putNamed(name, nil)
+		}
+		s.namedGen[name]++
+		L().Info("log_level_reverted", slog.String("name", name))
+	})
+}
+
+// ResetNamedLevel 撤销运行期对指定名称的修改：恢复 log.levels 中配置的级别，未配置时使用全局级别
+func ResetNamedLevel(name string) {
+	s := levels
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if level, ok := s.configured[name]; ok {
+		s.putNamed(name, &override{level: level})
+	} else {
+		s.putNamed(name, nil)
+	}
+	s.namedGen[name]++
+	L().Info("log_level_changed", slog.String("name", name), slog.String("level", "default"))
+}
+
+// configure 应用 log.levels 配置，替换之前配置的组件级别
+func (s *levelState) configure(cfg map[string]string) error {
+	configured := make(map[string]slog.Level, len(cfg))
+	for name, v := range cfg {
+		level, err := ParseLevel(v)
+		if err != nil {
+			return fmt.Errorf("log: levels.%s: invalid level %q", name, v)
+		}
+		configured[name] = level
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range s.configured {
+		s.putNamed(name, nil)
+		s.namedGen[name]++
+	}
+	for name, level := range configured {
+		s.putNamed(name, &override{level: level})
+		s.namedGen[name]++
+	}
+	s.configured = configured
+	return nil
+}
+
+// putNamed 替换覆盖表，o 为 nil 时删除
+func (s *levelState) putNamed(name string, o *override) {
+	old := *s.named.Load()
+	next := make(map[string]override, len(old)+1)
+	for k, v := range old {
+		next[k] = v
+	}
+	if o == nil {
+		delete(next, name)
+	} else {
+		next[name] = *o
+	}
+	s.named.Store(&next)
+}
+
+// ToggleDebug 在 debug 与切换前的全局级别之间切换 (SIGUSR1)，返回切换后的级别
+func ToggleDebug() slog.Level {
+	s := levels
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.toggled != nil {
+		prev := *s.toggled
+		s.toggled = nil
+		s.setGlobal(prev, 0)
+		return prev
+	}
+	prev := s.global.Level()
+	s.toggled = &prev
+	s.setGlobal(slog.LevelDebug, 0)
+	return slog.LevelDebug
+}
+
+// Levels 返回当前全局级别与所有覆盖
+func Levels() LevelInfo {
+	s := levels
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := LevelInfo{Level: s.global.Level().String(), Overrides: []LevelOverride{}}
+	if !s.globalUntil.IsZero() {
+		until := s.globalUntil
+		info.ExpiresAt = &until
+	}
+	for name, o := range *s.named.Load() {
+		lo := LevelOverride{Name: name, Level: o.level.String()}
+		if !o.expiresAt.IsZero() {
+			until := o.expiresAt
+			lo.ExpiresAt = &until
+		}
+		info.Overrides = append(info.Overrides, lo)
+	}
+	sort.Slice(info.Overrides, func(i, j int) bool { return info.Overrides[i].Name < info.Overrides[j].Name })
+	return info
+}
+
+// levelHandler 按全局级别或名称覆盖过滤日志；名称取自 With 添加的 ComponentKey 属性
+type levelHandler struct {
+	slog.Handler
+	name   string
+	nested bool // 已进入分组，之后的 ComponentKey 属性不再视为名称
+}
+
+func (h *levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return levels.enabled(h.name, level)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	name := h.name
+	if !h.nested {
+		for _, a := range attrs {
+			if a.Key == ComponentKey && a.Value.Kind() == slog.KindString {
+				name = a.Value.String()
+			}
+		}
+	}
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), name: name, nested: h.nested}
+}
+
+func (h *levelHandler) WithGroup(group string) slog.Handler {
+	if group == "" {
+		return h
+	}
+	return &levelHandler{Handler: h.Handler.WithGroup(group), name: h.name, nested: true}
+}
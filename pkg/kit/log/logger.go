@@ -3,7 +3,6 @@ package log
 
 import (
 	"log/slog"
-	"os"
 	"strings"
 	"sync"
 )
@@ -13,8 +12,8 @@ var (
 	once         sync.Once
 )
 
-// NewLogger 创建 slog 实例
-func NewLogger(cfg Config) *slog.Logger {
+// NewLogger 创建 slog 实例，按 cfg.Outputs 输出到 stdout / stderr / 文件
+func NewLogger(cfg Config) (*slog.Logger, error) {
 	var level slog.Level
 	switch strings.ToLower(cfg.Level) {
 	case "debug":
@@ -27,20 +26,30 @@ func NewLogger(cfg Config) *slog.Logger {
 		level = slog.LevelInfo
 	}
 
+	// 级别可在运行期修改 (SetLevel / SetNamedLevel / SIGUSR1)
+	levels.global.Set(level)
+	if err := levels.configure(cfg.Levels); err != nil {
+		return nil, err
+	}
 	opts := &slog.HandlerOptions{
 		AddSource: cfg.Source,
-		Level:     level,
+		Level:     &levels.global,
+	}
+
+	out, err := openOutputs(cfg.Outputs)
+	if err != nil {
+		return nil, err
 	}
 
 	var handler slog.Handler
 	if strings.ToLower(cfg.Format) == "text" {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+		handler = slog.NewTextHandler(out, opts)
 	} else {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+		handler = slog.NewJSONHandler(out, opts)
 	}
 
-	// 包装 TraceHandler
-	logger := slog.New(&TraceHandler{Handler: handler})
+	// 包装 TraceHandler，最外层按全局级别与名称覆盖过滤
+	logger := slog.New(&levelHandler{Handler: NewTraceHandler(handler)})
 
 	// 设置为全局默认，方便非依赖注入场景使用 slog.Info()
 	slog.SetDefault(logger)
@@ -50,7 +59,7 @@ func NewLogger(cfg Config) *slog.Logger {
 		globalLogger = logger
 	})
 
-	return logger
+	return logger, nil
 }
 
 // L 获取全局 Logger (可选)
@@ -60,3 +69,13 @@ func L() *slog.Logger {
 	}
 	return globalLogger
 }
+
+// Named 基于全局 Logger 创建组件日志器，日志附带 component=name，级别可通过 log.levels 单独配置
+func Named(name string) *slog.Logger {
+	return NamedFrom(L(), name)
+}
+
+// NamedFrom 基于 l 创建组件日志器，用于构造函数中的注入 Logger
+func NamedFrom(l *slog.Logger, name string) *slog.Logger {
+	return l.With(slog.String(ComponentKey, name))
+}
@@ -0,0 +1,133 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"go.uber.org/fx"
+)
+
+var (
+	filesMu sync.Mutex
+	// files 已打开的日志文件，同一路径只打开一次，避免多个 Logger 同时切割同一个文件
+	files = map[string]*FileWriter{}
+)
+
+// openOutputs 按配置打开所有输出目标
+func openOutputs(outputs []OutputConfig) (io.Writer, error) {
+	if len(outputs) == 0 {
+		return os.Stdout, nil
+	}
+	writers := make([]io.Writer, 0, len(outputs))
+	for _, o := range outputs {
+		switch strings.ToLower(o.Type) {
+		case "", "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		case "file":
+			w, err := openFile(o)
+			if err != nil {
+				return nil, err
+			}
+			writers = append(writers, w)
+		default:
+			return nil, fmt.Errorf("log: unknown output type %q", o.Type)
+		}
+	}
+	if len(writers) == 1 {
+		return writers[0], nil
+	}
+	return multiWriter(writers), nil
+}
+
+func openFile(cfg OutputConfig) (*FileWriter, error) {
+	path, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("log: file output path: %w", err)
+	}
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	if w, ok := files[path]; ok {
+		return w, nil
+	}
+	cfg.Path = path
+	w, err := NewFileWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	files[path] = w
+	return w, nil
+}
+
+// multiWriter 依次写入所有目标，某个目标失败不影响其他目标
+type multiWriter []io.Writer
+
+func (m multiWriter) Write(p []byte) (int, error) {
+	var errs []error
+	for _, w := range m {
+		if _, err := w.Write(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+// Reopen 重新打开所有日志文件，logrotate 移走文件后调用 (StartLifecycle 会在收到 SIGHUP 时自动调用)
+func Reopen() error {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	var errs []error
+	for _, w := range files {
+		errs = append(errs, w.Reopen())
+	}
+	return errors.Join(errs...)
+}
+
+// StartLifecycle 收到 SIGHUP 时重新打开日志文件，收到 SIGUSR1 时切换 debug 级别，停止时将日志文件刷盘
+func StartLifecycle(lc fx.Lifecycle, l *slog.Logger) {
+	l = NamedFrom(l, "log")
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(sig, append([]os.Signal{syscall.SIGHUP}, debugSignals...)...)
+			go func() {
+				defer close(done)
+				for s := range sig {
+					if s != syscall.SIGHUP {
+						ToggleDebug()
+						continue
+					}
+					if err := Reopen(); err != nil {
+						fmt.Fprintf(os.Stderr, "log: reopen: %v\n", err)
+						continue
+					}
+					l.Info("log_reopened")
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			signal.Stop(sig)
+			close(sig)
+			<-done
+			filesMu.Lock()
+			defer filesMu.Unlock()
+			var errs []error
+			for _, w := range files {
+				errs = append(errs, w.Sync())
+			}
+			return errors.Join(errs...)
+		},
+	})
+}
@@ -0,0 +1,304 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 切割后文件名中的时间戳：app.log -> app-2006-01-02T15-04-05.000.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileWriter 按大小与日期切割的日志文件，切割后的文件按时长与个数清理，可选 gzip 压缩。
+// 并发安全；slog 的 Handler 每条记录只调用一次 Write，因此切割不会拆开单条日志
+type FileWriter struct {
+	cfg OutputConfig
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time // 当前文件覆盖时段的起点，跨天时切割，并作为备份文件名的时间戳
+
+	mill     chan struct{}
+	millOnce sync.Once
+}
+
+// NewFileWriter 打开 (或追加) cfg.Path 对应的日志文件，目录不存在时自动创建
+func NewFileWriter(cfg OutputConfig) (*FileWriter, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("log: file output requires path")
+	}
+	w := &FileWriter{cfg: cfg}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	now := time.Now()
+	if (w.cfg.Daily && now.Format(time.DateOnly) != w.opened.Format(time.DateOnly)) ||
+		(w.cfg.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > int64(w.cfg.MaxSize)*1024*1024) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Reopen 关闭并重新打开日志文件，配合 logrotate 等外部工具移走文件后使用 (SIGHUP)
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.close(); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// Rotate 立即切割当前文件
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+// Sync 将缓冲写入磁盘
+func (w *FileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.close()
+}
+
+func (w *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.cfg.Path), 0o755); err != nil {
+		return fmt.Errorf("log: create log dir: %w", err)
+	}
+	f, err := os.OpenFile(w.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("log: open %s: %w", w.cfg.Path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("log: stat %s: %w", w.cfg.Path, err)
+	}
+	w.file, w.size = f, info.Size()
+	// 追加已有文件时沿用其修改时间，进程跨天重启后首次写入即切割
+	w.opened = time.Now()
+	if w.size > 0 {
+		w.opened = info.ModTime()
+	}
+	return nil
+}
+
+func (w *FileWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// rotate 将当前文件重命名为以其起始时间命名的备份并打开新文件，之后在后台压缩与清理。
+// 按天切割时备份名即为其覆盖的日期
+func (w *FileWriter) rotate() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(w.cfg.Path); err == nil {
+		if err := os.Rename(w.cfg.Path, w.backupName(w.opened)); err != nil {
+			return fmt.Errorf("log: rotate %s: %w", w.cfg.Path, err)
+		}
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.startMill()
+	return nil
+}
+
+// backupName 返回时间戳为 t 的备份路径，与已有备份 (含压缩后的) 重名时顺延 1ms
+func (w *FileWriter) backupName(t time.Time) string {
+	dir, prefix, ext := w.nameParts()
+	for {
+		name := filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext)
+		if !exists(name) && !exists(name+".gz") {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return !os.IsNotExist(err)
+}
+
+func (w *FileWriter) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.cfg.Path)
+	name := filepath.Base(w.cfg.Path)
+	ext = filepath.Ext(name)
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// startMill 后台协程串行执行压缩与清理，多次切割只排队一次
+func (w *FileWriter) startMill() {
+	w.millOnce.Do(func() {
+		w.mill = make(chan struct{}, 1)
+		go func() {
+			for range w.mill {
+				w.millRun()
+			}
+		}()
+	})
+	select {
+	case w.mill <- struct{}{}:
+	default:
+	}
+}
+
+type backup struct {
+	path    string
+	t       time.Time // 文件名中的起始时间，用于排序
+	rotated time.Time // 修改时间，即最后一次写入 (切割) 的时间，用于 MaxAge
+}
+
+// millRun 压缩未压缩的备份，并删除超过 MaxBackups 个或切割时间早于 MaxAge 的备份。错误写入 stderr，避免日志递归
+func (w *FileWriter) millRun() {
+	backups, err := w.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log: list backups: %v\n", err)
+		return
+	}
+	var remove []backup
+	if w.cfg.MaxBackups > 0 && len(backups) > w.cfg.MaxBackups {
+		remove = append(remove, backups[w.cfg.MaxBackups:]...)
+		backups = backups[:w.cfg.MaxBackups]
+	}
+	if w.cfg.MaxAge > 0 {
+		cutoff := time.Now().Add(-w.cfg.MaxAge)
+		kept := backups[:0]
+		for _, b := range backups {
+			if b.rotated.Before(cutoff) {
+				remove = append(remove, b)
+			} else {
+				kept = append(kept, b)
+			}
+		}
+		backups = kept
+	}
+	for _, b := range remove {
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "log: remove backup: %v\n", err)
+		}
+	}
+	if !w.cfg.Compress {
+		return
+	}
+	for _, b := range backups {
+		if strings.HasSuffix(b.path, ".gz") {
+			continue
+		}
+		if err := gzipFile(b.path); err != nil {
+			fmt.Fprintf(os.Stderr, "log: compress backup: %v\n", err)
+		}
+	}
+}
+
+// backups 返回切割后的文件，按时间从新到旧排序
+func (w *FileWriter) backups() ([]backup, error) {
+	dir, prefix, ext := w.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(ts, ".gz")
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(ts, ext), time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, backup{path: filepath.Join(dir, name), t: t, rotated: info.ModTime()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].t.After(out[j].t) })
+	return out, nil
+}
+
+// gzipFile 压缩为 path.gz 后删除原文件，保留原文件的修改时间
+func gzipFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	if err = os.Chtimes(path+".gz", info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMillMaxAge(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	type file struct {
+		start   time.Time // 文件名中的起始时间
+		rotated time.Time // 修改时间
+		gz      bool
+	}
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		compress bool
+		files    []file
+		want     []int // 保留的文件下标
+	}{
+		{"slow size rotation kept", 7 * day, false, []file{
+			{start: now.Add(-10 * day), rotated: now.Add(-time.Minute)},
+		}, []int{0}},
+		{"daily file lives full max_age", day, false, []file{
+			{start: now.Add(-30 * time.Hour), rotated: now.Add(-7 * time.Hour)},
+		}, []int{0}},
+		{"rotated before max_age removed", 7 * day, false, []file{
+			{start: now.Add(-9 * day), rotated: now.Add(-8 * day)},
+			{start: now.Add(-8 * day), rotated: now.Add(-6 * day)},
+		}, []int{1}},
+		{"compressed uses original rotation time", 7 * day, true, []file{
+			{start: now.Add(-9 * day), rotated: now.Add(-8 * day), gz: true},
+			{start: now.Add(-20 * day), rotated: now.Add(-time.Hour)},
+		}, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w := &FileWriter{cfg: OutputConfig{Path: filepath.Join(dir, "app.log"), MaxAge: tt.maxAge, Compress: tt.compress}}
+			paths := make([]string, len(tt.files))
+			for i, f := range tt.files {
+				paths[i] = w.backupName(f.start)
+				if f.gz {
+					paths[i] += ".gz"
+				}
+				if err := os.WriteFile(paths[i], []byte("x\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(paths[i], f.rotated, f.rotated); err != nil {
+					t.Fatal(err)
+				}
+			}
+			w.millRun()
+
+			var kept []int
+			for i, p := range paths {
+				if tt.compress && !tt.files[i].gz {
+					p += ".gz"
+				}
+				info, err := os.Stat(p)
+				if os.IsNotExist(err) {
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !info.ModTime().Equal(tt.files[i].rotated) {
+					t.Fatalf("%s mtime = %s, want %s", p, info.ModTime(), tt.files[i].rotated)
+				}
+				kept = append(kept, i)
+			}
+			if !slices.Equal(kept, tt.want) {
+				t.Fatalf("kept %v, want %v", kept, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotateNamesAfterStart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-10 * 24 * time.Hour).Truncate(time.Millisecond)
+	if err := os.Chtimes(path, start, start); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewFileWriter(OutputConfig{Path: path, MaxAge: 7 * 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	w.millRun()
+
+	backups, err := w.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || !backups[0].t.Equal(start) {
+		t.Fatalf("backups = %+v, want one named %s", backups, start)
+	}
+	if data, _ := os.ReadFile(backups[0].path); string(data) != "old\nnew\n" {
+		t.Fatalf("backup content = %q", data)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package log
+
+import "os"
+
+// debugSignals 非 Unix 平台没有 SIGUSR1，只能通过管理接口修改级别
+var debugSignals []os.Signal
@@ -0,0 +1,11 @@
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// debugSignals 切换 debug 级别的信号
+var debugSignals = []os.Signal{syscall.SIGUSR1}
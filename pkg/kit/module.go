@@ -2,6 +2,7 @@ package kit
 
 import (
 	"goKit/pkg/kit/db"
+	"goKit/pkg/kit/health"
 	"goKit/pkg/kit/log"
 	"goKit/pkg/kit/rpc"
 	"goKit/pkg/kit/web"
@@ -12,7 +13,10 @@ import (
 var Module = fx.Options(
 	// 1. 优先提供 Logger (因为其他组件都依赖它)
 	fx.Provide(log.NewLogger),
+	fx.Invoke(log.StartLifecycle),
+	fx.Provide(health.NewRegistry),
 	fx.Provide(db.NewClient),
+	fx.Invoke(db.StartLifecycle),
 	fx.Provide(web.NewServer),
 	fx.Invoke(web.StartLifecycle),
 	fx.Provide(rpc.NewServer),
@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"context"
+
+	"goKit/pkg/kit/db"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorInterceptor 将未转换为 gRPC status 的基础设施错误映射为标准状态码：
+// db.ErrQueryTimeout -> DeadlineExceeded
+func ErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, toStatus(err)
+	}
+}
+
+// ErrorStreamInterceptor 流式请求的错误映射，规则同 ErrorInterceptor
+func ErrorStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatus(handler(srv, ss))
+	}
+}
+
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if db.IsTimeout(err) {
+		return status.Error(codes.DeadlineExceeded, "query timeout")
+	}
+	return err
+}
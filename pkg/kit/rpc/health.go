@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"context"
+
+	"goKit/pkg/kit/health"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthServer 实现 grpc.health.v1.Health，每次 Check 实时读取健康检查注册表。
+// service 为空时检查全部组件，否则检查同名组件 (如 db、db:orders)
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	reg *health.Registry
+}
+
+func (h *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	up := false
+	if req.GetService() == "" {
+		up = h.reg.Check(ctx).Healthy()
+	} else {
+		c, ok := h.reg.CheckOne(ctx, req.GetService())
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+		}
+		up = c.Status == health.StatusUp
+	}
+	resp := &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}
+	if up {
+		resp.Status = healthpb.HealthCheckResponse_SERVING
+	}
+	return resp, nil
+}
@@ -7,11 +7,15 @@ import (
 	"runtime/debug"
 	"time"
 
+	"goKit/pkg/kit/health"
+	"goKit/pkg/kit/log"
+
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/status"
 )
@@ -22,6 +26,7 @@ type ServerParams struct {
 
 	Config Config
 	Logger *slog.Logger
+	Health *health.Registry `optional:"true"`
 
 	// 【新增】AuthFunc 认证函数 (可选注入)
 	// 如果没有 Provide 这个函数，Fx 会将其置为 nil
@@ -33,6 +38,8 @@ type ServerParams struct {
 }
 
 func NewServer(params ServerParams) *grpc.Server {
+	l := log.NamedFrom(params.Logger, "rpc")
+
 	// 1. KeepAlive 参数配置
 	kaParams := grpc.KeepaliveParams(keepalive.ServerParameters{
 		MaxConnectionIdle: params.Config.MaxConnectionIdle,
@@ -42,21 +49,23 @@ func NewServer(params ServerParams) *grpc.Server {
 
 	// ---------------------------------------------------------
 	// 2. 组装 Unary (一元) 拦截器链
-	// 顺序: Recovery -> Validator -> Auth -> Custom
+	// 顺序: Recovery -> Error -> Validator -> Auth -> Custom
 	// ---------------------------------------------------------
 	unaryChain := []grpc.UnaryServerInterceptor{
 		// 1. Panic 恢复 (最外层，兜底)
-		RecoverInterceptor(params.Logger),
-		// 2. 参数校验 (依赖 proto 生成的 Validate 方法)
+		RecoverInterceptor(l),
+		// 2. 基础设施错误映射 (如查询超时 -> DeadlineExceeded)
+		ErrorInterceptor(),
+		// 3. 参数校验 (依赖 proto 生成的 Validate 方法)
 		validator.UnaryServerInterceptor(),
 	}
 
-	// 3. 认证 (如果有注入 AuthFunc)
+	// 4. 认证 (如果有注入 AuthFunc)
 	if params.AuthFunc != nil {
 		unaryChain = append(unaryChain, auth.UnaryServerInterceptor(params.AuthFunc))
 	}
 
-	// 4. 自定义/业务拦截器 (Logging, Tracing, Metrics 等)
+	// 5. 自定义/业务拦截器 (Logging, Tracing, Metrics 等)
 	unaryChain = append(unaryChain, params.UnaryInterceptors...)
 
 	// ---------------------------------------------------------
@@ -64,7 +73,8 @@ func NewServer(params ServerParams) *grpc.Server {
 	// 保持与 Unary 相同的逻辑顺序
 	// ---------------------------------------------------------
 	streamChain := []grpc.StreamServerInterceptor{
-		RecoverStreamInterceptor(params.Logger),
+		RecoverStreamInterceptor(l),
+		ErrorStreamInterceptor(),
 		validator.StreamServerInterceptor(),
 	}
 
@@ -83,7 +93,13 @@ func NewServer(params ServerParams) *grpc.Server {
 		grpc.ChainStreamInterceptor(streamChain...),
 	}
 
-	return grpc.NewServer(opts...)
+	s := grpc.NewServer(opts...)
+
+	// 5. 标准健康检查服务 (grpc.health.v1)，供 K8s gRPC 探针与负载均衡使用
+	if params.Health != nil {
+		healthpb.RegisterHealthServer(s, &healthServer{reg: params.Health})
+	}
+	return s
 }
 
 // RecoverInterceptor 一元请求 Panic 恢复
@@ -120,6 +136,7 @@ func RecoverStreamInterceptor(l *slog.Logger) grpc.StreamServerInterceptor {
 
 // StartLifecycle 生命周期管理
 func StartLifecycle(lc fx.Lifecycle, s *grpc.Server, cfg Config, l *slog.Logger) {
+	l = log.NamedFrom(l, "rpc")
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			go func() {
@@ -4,4 +4,25 @@ type Config struct {
 	Port    string `mapstructure:"port"`
 	AppName string `mapstructure:"app_name"`
 	Prefork bool   `mapstructure:"prefork"`
+	// Prometheus 指标路径，默认 /metrics，设为 "-" 关闭
+	MetricsPath string `mapstructure:"metrics_path"`
+	// 运维接口 (/admin)，默认关闭
+	Admin AdminConfig `mapstructure:"admin"`
+}
+
+// AdminConfig 运维接口配置，开启时必须设置 Token，请求需携带 Authorization: Bearer <token>
+type AdminConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Token   string `mapstructure:"token"`
+}
+
+func (c Config) metricsPath() string {
+	switch c.MetricsPath {
+	case "":
+		return "/metrics"
+	case "-":
+		return ""
+	default:
+		return c.MetricsPath
+	}
 }
@@ -0,0 +1,52 @@
+package idempotency
+
+import "time"
+
+type Config struct {
+	Store           string        `mapstructure:"store"`            // memory: 进程内存 (单实例) | db: 数据库表 (多实例共享)
+	Table           string        `mapstructure:"table"`            // db 存储使用的表，首次使用时自动创建
+	Header          string        `mapstructure:"header"`           // 幂等键请求头
+	Methods         []string      `mapstructure:"methods"`          // 生效的请求方法
+	TTL             time.Duration `mapstructure:"ttl"`              // 已完成请求的响应保留时长，期间同一个键的重试直接回放
+	LockTimeout     time.Duration `mapstructure:"lock_timeout"`     // 处理中记录的最长占用时间，超过后视为请求已中断，允许重试
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"` // 过期记录清理间隔
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Store:           "memory",
+		Table:           "kit_idempotency_keys",
+		Header:          "Idempotency-Key",
+		Methods:         []string{"POST", "PATCH"},
+		TTL:             24 * time.Hour,
+		LockTimeout:     time.Minute,
+		CleanupInterval: time.Hour,
+	}
+}
+
+// withDefaults 未配置的字段使用默认值
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.Store == "" {
+		c.Store = def.Store
+	}
+	if c.Table == "" {
+		c.Table = def.Table
+	}
+	if c.Header == "" {
+		c.Header = def.Header
+	}
+	if len(c.Methods) == 0 {
+		c.Methods = def.Methods
+	}
+	if c.TTL <= 0 {
+		c.TTL = def.TTL
+	}
+	if c.LockTimeout <= 0 {
+		c.LockTimeout = def.LockTimeout
+	}
+	if c.CleanupInterval <= 0 {
+		c.CleanupInterval = def.CleanupInterval
+	}
+	return c
+}
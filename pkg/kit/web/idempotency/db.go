@@ -0,0 +1,132 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"goKit/pkg/kit/db"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// keyRow 幂等键表记录，Status 为 0 表示处理中
+type keyRow struct {
+	Key         string            `gorm:"column:idempotency_key;primaryKey;size:191"`
+	Owner       string            `gorm:"size:64;not null"`
+	Fingerprint string            `gorm:"size:64;not null"`
+	Status      int               `gorm:"not null;default:0"`
+	Header      map[string]string `gorm:"type:text;serializer:json"`
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (r *keyRow) record() *Record {
+	rec := &Record{Key: r.Key, Owner: r.Owner, Fingerprint: r.Fingerprint, ExpiresAt: r.ExpiresAt}
+	if r.Status != 0 {
+		rec.Response = &Response{Status: r.Status, Header: r.Header, Body: r.Body}
+	}
+	return rec
+}
+
+// DBStore 数据库存储，多实例共享。过期判断使用各实例的本地时钟，需保证时钟同步
+type DBStore struct {
+	client *db.Client
+	table  string
+
+	once       sync.Once
+	migrateErr error
+}
+
+func NewDBStore(client *db.Client, table string) *DBStore {
+	return &DBStore{client: client, table: table}
+}
+
+// keys 返回幂等键表会话，首次使用时建表。读写走主库且不复用 ctx 中的事务；表属于公共库，不区分租户
+func (s *DBStore) keys(ctx context.Context) (*gorm.DB, error) {
+	conn := func(ctx context.Context) *gorm.DB {
+		return s.client.GetDB(db.WithAllTenants(db.WithPrimary(context.Background()))).WithContext(ctx).Table(s.table)
+	}
+	s.once.Do(func() {
+		s.migrateErr = conn(context.Background()).AutoMigrate(&keyRow{})
+	})
+	if s.migrateErr != nil {
+		return nil, s.migrateErr
+	}
+	return conn(ctx), nil
+}
+
+// Acquire 插入处理中记录；已存在时仅在过期后接管，否则返回已有记录
+func (s *DBStore) Acquire(ctx context.Context, rec *Record) (*Record, error) {
+	tx, err := s.keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 已有记录可能在插入与读取之间被释放，重试几次
+	for range 3 {
+		row := keyRow{Key: rec.Key, Owner: rec.Owner, Fingerprint: rec.Fingerprint, ExpiresAt: rec.ExpiresAt}
+		res := tx.Session(&gorm.Session{}).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected > 0 {
+			return nil, nil
+		}
+
+		res = tx.Session(&gorm.Session{}).
+			Where("idempotency_key = ? AND expires_at < ?", rec.Key, time.Now()).
+			Updates(map[string]any{
+				"owner":       rec.Owner,
+				"fingerprint": rec.Fingerprint,
+				"status":      0,
+				"header":      nil,
+				"body":        nil,
+				"expires_at":  rec.ExpiresAt,
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected > 0 {
+			return nil, nil
+		}
+
+		var existing keyRow
+		err := tx.Session(&gorm.Session{}).Where("idempotency_key = ?", rec.Key).Take(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return existing.record(), nil
+	}
+	return nil, errors.New("idempotency: acquire contention")
+}
+
+func (s *DBStore) Complete(ctx context.Context, key, owner string, resp *Response, expiresAt time.Time) error {
+	tx, err := s.keys(ctx)
+	if err != nil {
+		return err
+	}
+	return tx.Where("idempotency_key = ? AND owner = ? AND status = 0", key, owner).
+		Updates(&keyRow{Status: resp.Status, Header: resp.Header, Body: resp.Body, ExpiresAt: expiresAt}).Error
+}
+
+func (s *DBStore) Release(ctx context.Context, key, owner string) error {
+	tx, err := s.keys(ctx)
+	if err != nil {
+		return err
+	}
+	return tx.Where("idempotency_key = ? AND owner = ? AND status = 0", key, owner).Delete(&keyRow{}).Error
+}
+
+func (s *DBStore) Purge(ctx context.Context) (int64, error) {
+	tx, err := s.keys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	res := tx.Where("expires_at < ?", time.Now()).Delete(&keyRow{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,211 @@
+// Package idempotency 基于 Idempotency-Key 请求头的幂等中间件，避免客户端重试 POST 时重复创建资源。
+// 首个请求处理期间，同一个键的并发请求返回 409；处理完成后，TTL 内的重试直接回放已保存的响应 (带 Idempotent-Replayed 头)；
+// 同一个键对应不同的请求 (方法、URL 或请求体不同) 返回 422。5xx 与未处理的错误不保存，客户端可以使用同一个键重试。
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"goKit/pkg/kit/log"
+	"goKit/pkg/kit/web"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+)
+
+// ReplayedHeader 回放的响应携带该头
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxKeyLen 幂等键最大长度
+const maxKeyLen = 128
+
+// replayHeaders 保存并回放的响应头
+var replayHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation}
+
+// ScopeFunc 返回调用方标识 (如用户或租户)，不同调用方的同名键互不影响
+type ScopeFunc func(c *fiber.Ctx) string
+
+// Module 注册幂等中间件与过期记录清理，需额外 Provide Config；
+// store: db 时依赖 *db.Client，可选 Provide ScopeFunc
+var Module = fx.Options(
+	fx.Provide(NewStore),
+	fx.Provide(web.AsMiddlewares(New)),
+	fx.Invoke(StartLifecycle),
+)
+
+// Params 注入参数
+type Params struct {
+	fx.In
+
+	Config Config
+	Store  Store
+	Logger *slog.Logger
+	Scope  ScopeFunc `optional:"true"`
+}
+
+// New 创建幂等中间件，只处理 Config.Methods 中且携带幂等键的请求
+func New(p Params) fiber.Handler {
+	cfg := p.Config.withDefaults()
+	m := &middleware{cfg: cfg, store: p.Store, l: log.NamedFrom(p.Logger, "idempotency"), scope: p.Scope}
+	return m.handle
+}
+
+type middleware struct {
+	cfg   Config
+	store Store
+	l     *slog.Logger
+	scope ScopeFunc
+}
+
+func (m *middleware) handle(c *fiber.Ctx) error {
+	key := c.Get(m.cfg.Header)
+	if key == "" || !slices.Contains(m.cfg.Methods, c.Method()) {
+		return c.Next()
+	}
+	if len(key) > maxKeyLen {
+		return fiber.NewError(fiber.StatusBadRequest, "idempotency key is too long")
+	}
+	// Fiber 返回的字符串复用请求缓冲区，日志可能在之后引用，需要拷贝
+	key = strings.Clone(key)
+	var scope string
+	if m.scope != nil {
+		scope = m.scope(c)
+	}
+
+	// 处理完成后 ctx 可能已被取消，存储操作仍需执行
+	ctx := context.WithoutCancel(c.UserContext())
+	rec := &Record{
+		Key:         storeKey(scope, key),
+		Owner:       newOwner(),
+		Fingerprint: fingerprint(c),
+		ExpiresAt:   time.Now().Add(m.cfg.LockTimeout),
+	}
+	existing, err := m.store.Acquire(ctx, rec)
+	if err != nil {
+		m.l.ErrorContext(ctx, "idempotency_acquire_failed", slog.String("key", key), slog.Any("err", err))
+		return err
+	}
+	if existing != nil {
+		return m.replay(c, rec, existing)
+	}
+
+	if err := c.Next(); err != nil {
+		// 错误尚未转换为响应 (由应用的 ErrorHandler 处理)，释放键允许重试
+		m.release(ctx, rec)
+		return err
+	}
+	status := c.Response().StatusCode()
+	if status >= fiber.StatusInternalServerError {
+		m.release(ctx, rec)
+		return nil
+	}
+
+	resp := &Response{Status: status, Body: bytes.Clone(c.Response().Body())}
+	for _, h := range replayHeaders {
+		if v := c.GetRespHeader(h); v != "" {
+			if resp.Header == nil {
+				resp.Header = map[string]string{}
+			}
+			resp.Header[h] = strings.Clone(v)
+		}
+	}
+	if err := m.store.Complete(ctx, rec.Key, rec.Owner, resp, time.Now().Add(m.cfg.TTL)); err != nil {
+		// 响应已生成，保存失败只影响之后的重试
+		m.l.WarnContext(ctx, "idempotency_save_failed", slog.String("key", key), slog.Any("err", err))
+		m.release(ctx, rec)
+	}
+	return nil
+}
+
+// storeKey 存储使用的键：调用方标识与幂等键的 sha256，长度固定 (64)，不受 scope 长度影响。
+// scope 带长度前缀，避免 "a:b"+"c" 与 "a"+"b:c" 冲突
+func storeKey(scope, key string) string {
+	sum := sha256.Sum256([]byte(strconv.Itoa(len(scope)) + ":" + scope + ":" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// replay 处理已存在的键：请求不一致返回 422，处理中返回 409，否则回放已保存的响应
+func (m *middleware) replay(c *fiber.Ctx, rec, existing *Record) error {
+	if existing.Fingerprint != rec.Fingerprint {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "idempotency key was used for a different request")
+	}
+	if existing.Response == nil {
+		return fiber.NewError(fiber.StatusConflict, "a request with the same idempotency key is in progress")
+	}
+	for h, v := range existing.Response.Header {
+		c.Set(h, v)
+	}
+	c.Set(ReplayedHeader, "true")
+	return c.Status(existing.Response.Status).Send(existing.Response.Body)
+}
+
+func (m *middleware) release(ctx context.Context, rec *Record) {
+	if err := m.store.Release(ctx, rec.Key, rec.Owner); err != nil {
+		// 释放失败时记录在 LockTimeout 后过期
+		m.l.WarnContext(ctx, "idempotency_release_failed", slog.String("key", rec.Key), slog.Any("err", err))
+	}
+}
+
+// fingerprint 请求指纹：方法 + URL (含查询参数) + 请求体
+func fingerprint(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.OriginalURL()))
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func newOwner() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// StartLifecycle 定期清理过期记录
+func StartLifecycle(lc fx.Lifecycle, cfg Config, store Store, l *slog.Logger) {
+	cfg = cfg.withDefaults()
+	l = log.NamedFrom(l, "idempotency")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(cfg.CleanupInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+					}
+					n, err := store.Purge(ctx)
+					if err != nil {
+						l.Warn("idempotency_purge_failed", slog.Any("err", err))
+						continue
+					}
+					if n > 0 {
+						l.Debug("idempotency_purged", slog.Int64("rows", n))
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			<-done
+			return nil
+		},
+	})
+}
@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"goKit/pkg/kit/db"
+
+	"go.uber.org/fx"
+)
+
+// Record 幂等键记录，Response 为 nil 表示请求仍在处理中
+type Record struct {
+	Key         string // 调用方标识与幂等键的摘要，见 storeKey
+	Owner       string // 占用该键的请求标识，Complete / Release 只对自己占用的记录生效
+	Fingerprint string // 请求指纹 (方法 + URL + 请求体)，同一个键对应不同请求时拒绝
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+// Response 需要回放的响应
+type Response struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   []byte            `json:"body,omitempty"`
+}
+
+// Store 幂等键存储
+type Store interface {
+	// Acquire 键不存在或已过期时写入处理中记录 rec 并返回 nil，否则返回已有记录
+	Acquire(ctx context.Context, rec *Record) (*Record, error)
+	// Complete 保存响应，记录保留到 expiresAt
+	Complete(ctx context.Context, key, owner string, resp *Response, expiresAt time.Time) error
+	// Release 删除处理中的记录，允许客户端使用同一个键重试
+	Release(ctx context.Context, key, owner string) error
+	// Purge 删除过期记录，返回删除条数
+	Purge(ctx context.Context) (int64, error)
+}
+
+// StoreParams 注入参数
+type StoreParams struct {
+	fx.In
+
+	Config Config
+	Client *db.Client `optional:"true"`
+}
+
+// NewStore 按 Config.Store 创建存储
+func NewStore(p StoreParams) (Store, error) {
+	cfg := p.Config.withDefaults()
+	switch cfg.Store {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "db":
+		if p.Client == nil {
+			return nil, fmt.Errorf("idempotency: db store requires *db.Client")
+		}
+		return NewDBStore(p.Client, cfg.Table), nil
+	default:
+		return nil, fmt.Errorf("idempotency: unknown store %q", cfg.Store)
+	}
+}
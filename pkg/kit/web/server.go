@@ -3,11 +3,17 @@ package web
 import (
 	"context"
 	"log/slog"
+	"strings"
+
+	"goKit/pkg/kit/health"
+	"goKit/pkg/kit/log"
 
 	"github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/fx"
 )
 
@@ -17,6 +23,7 @@ type ServerParams struct {
 
 	Config Config
 	Logger *slog.Logger
+	Health *health.Registry `optional:"true"`
 	// 使用 group 标签，Fx 会自动收集所有标记为 "http_global_middleware" 的 handler
 	Middlewares []fiber.Handler `group:"http_global_middleware"`
 }
@@ -34,16 +41,44 @@ func NewServer(params ServerParams) *fiber.App {
 	// 1. 内置基础中间件
 	app.Use(recover.New())
 	app.Use(requestid.New(requestid.Config{ContextKey: "requestid"}))
+	// 请求 ID 作为链路 ID 写入 UserContext，使用 c.UserContext() 记录的日志自动附带 trace_id
+	app.Use(func(c *fiber.Ctx) error {
+		if id, ok := c.Locals("requestid").(string); ok && id != "" {
+			// 客户端传入的请求 ID 复用请求缓冲区，ctx 可能在请求结束后仍被使用，需要拷贝
+			c.SetUserContext(log.WithTraceID(c.UserContext(), strings.Clone(id)))
+		}
+		return c.Next()
+	})
 
 	// 2. 挂载用户注入的全局中间件 (CORS, Limiter, Auth 等)
 	for _, m := range params.Middlewares {
 		app.Use(m)
 	}
 
+	// 3. Prometheus 指标 (包含 db 查询与连接池指标)
+	if path := params.Config.metricsPath(); path != "" {
+		app.Get(path, adaptor.HTTPHandler(promhttp.Handler()))
+	}
+
+	// 4. 健康检查：/healthz 存活探针，/readyz 就绪探针 (任一组件不可用时返回 503)
+	app.Get("/healthz", func(c *fiber.Ctx) error {
+		return c.JSON(health.Report{Status: health.StatusUp})
+	})
+	if params.Health != nil {
+		app.Get("/readyz", func(c *fiber.Ctx) error {
+			report := params.Health.Check(c.UserContext())
+			if !report.Healthy() {
+				c.Status(fiber.StatusServiceUnavailable)
+			}
+			return c.JSON(report)
+		})
+	}
+
 	return app
 }
 
 func StartLifecycle(lc fx.Lifecycle, app *fiber.App, cfg Config, l *slog.Logger) {
+	l = log.NamedFrom(l, "web")
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			go func() {